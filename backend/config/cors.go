@@ -0,0 +1,24 @@
+package config
+
+import (
+	"slices"
+	"strings"
+)
+
+// AllowedOrigins lists the browser origins that may call the API, from the
+// comma-separated CORS_ORIGINS variable.
+func AllowedOrigins() []string {
+	var origins []string
+	for _, origin := range strings.Split(getEnv("CORS_ORIGINS", "http://localhost:3000"), ",") {
+		if origin = strings.TrimSpace(origin); origin != "" {
+			origins = append(origins, origin)
+		}
+	}
+	return origins
+}
+
+// IsAllowedOrigin reports whether a request's Origin header may use the API.
+// Requests without one do not come from a browser page and are allowed.
+func IsAllowedOrigin(origin string) bool {
+	return origin == "" || slices.Contains(AllowedOrigins(), origin)
+}
@@ -0,0 +1,177 @@
+package controller
+
+import (
+	"backend/model"
+	"backend/storage"
+	"backend/utils"
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+const (
+	maxAttachmentSize     = 10 << 20
+	maxAttachmentsPerSend = 5
+	thumbnailSide         = 320
+)
+
+// allowedAttachmentTypes is checked against the sniffed content type, never
+// the one the client claims.
+var allowedAttachmentTypes = map[string]bool{
+	"image/png":       true,
+	"image/jpeg":      true,
+	"image/gif":       true,
+	"image/webp":      true,
+	"application/pdf": true,
+	"text/plain":      true,
+}
+
+type attachmentError struct {
+	status  int
+	message string
+}
+
+func (e *attachmentError) Error() string {
+	return e.message
+}
+
+// storeAttachments validates and stores uploaded files for a channel. The
+// returned attachments are not yet saved; on error nothing is left in storage.
+func storeAttachments(channel model.Channel, files []*multipart.FileHeader) ([]model.Attachment, error) {
+	if len(files) > maxAttachmentsPerSend {
+		return nil, &attachmentError{fiber.StatusBadRequest, fmt.Sprintf("At most %d files per message", maxAttachmentsPerSend)}
+	}
+
+	var stored []model.Attachment
+	for _, fh := range files {
+		attachment, err := storeAttachment(channel, fh)
+		if err != nil {
+			deleteStoredAttachments(stored)
+			return nil, err
+		}
+		stored = append(stored, attachment)
+	}
+	return stored, nil
+}
+
+func storeAttachment(channel model.Channel, fh *multipart.FileHeader) (model.Attachment, error) {
+	if fh.Size > maxAttachmentSize {
+		return model.Attachment{}, &attachmentError{fiber.StatusRequestEntityTooLarge, fmt.Sprintf("%s exceeds the %d MB limit", fh.Filename, maxAttachmentSize>>20)}
+	}
+
+	f, err := fh.Open()
+	if err != nil {
+		return model.Attachment{}, err
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(io.LimitReader(f, maxAttachmentSize+1))
+	if err != nil {
+		return model.Attachment{}, err
+	}
+	if len(data) > maxAttachmentSize {
+		return model.Attachment{}, &attachmentError{fiber.StatusRequestEntityTooLarge, fmt.Sprintf("%s exceeds the %d MB limit", fh.Filename, maxAttachmentSize>>20)}
+	}
+
+	mimeType, _, _ := strings.Cut(http.DetectContentType(data), ";")
+	if !allowedAttachmentTypes[mimeType] {
+		return model.Attachment{}, &attachmentError{fiber.StatusUnsupportedMediaType, fmt.Sprintf("%s has an unsupported file type", fh.Filename)}
+	}
+
+	base := fmt.Sprintf("%d/%d/%s", channel.TenantID, channel.ID, utils.GenerateSessionID())
+	attachment := model.Attachment{
+		TenantID:   channel.TenantID,
+		ChannelID:  channel.ID,
+		FileName:   filepath.Base(fh.Filename),
+		MimeType:   mimeType,
+		Size:       int64(len(data)),
+		StorageKey: base + strings.ToLower(filepath.Ext(fh.Filename)),
+	}
+
+	if err := storage.Default().Put(attachment.StorageKey, bytes.NewReader(data)); err != nil {
+		return model.Attachment{}, err
+	}
+
+	if strings.HasPrefix(mimeType, "image/") {
+		if thumb, ok := utils.Thumbnail(data, thumbnailSide); ok {
+			key := base + "_thumb.jpg"
+			if err := storage.Default().Put(key, bytes.NewReader(thumb)); err == nil {
+				attachment.ThumbnailKey = key
+			}
+		}
+	}
+
+	return attachment, nil
+}
+
+func deleteStoredAttachments(attachments []model.Attachment) {
+	for _, a := range attachments {
+		if err := storage.Default().Delete(a.StorageKey); err != nil {
+			log.Printf("attachment cleanup %s: %v", a.StorageKey, err)
+		}
+		if a.ThumbnailKey != "" {
+			storage.Default().Delete(a.ThumbnailKey)
+		}
+	}
+}
+
+func GetAttachment(c fiber.Ctx) error {
+	userID, ok := c.Locals("user_id").(uint)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   true,
+			"message": "Unauthorized",
+		})
+	}
+
+	role, _ := c.Locals("role").(string)
+
+	var attachment model.Attachment
+	if err := tenantDB(c).First(&attachment, c.Params("id")).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Attachment not found",
+		})
+	}
+
+	var channel model.Channel
+	if err := tenantDB(c).First(&channel, attachment.ChannelID).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Attachment not found",
+		})
+	}
+
+	if allowed, message := canAccessChannel(channel, userID, role); !allowed {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error":   true,
+			"message": message,
+		})
+	}
+
+	key, mimeType := attachment.StorageKey, attachment.MimeType
+	if c.Query("thumbnail") != "" && attachment.ThumbnailKey != "" {
+		key, mimeType = attachment.ThumbnailKey, "image/jpeg"
+	}
+
+	r, err := storage.Default().Open(key)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Attachment file is missing",
+		})
+	}
+
+	c.Set("Content-Type", mimeType)
+	c.Set("Content-Disposition", fmt.Sprintf("inline; filename=%q", attachment.FileName))
+	c.Set("X-Content-Type-Options", "nosniff")
+	c.Set("Cache-Control", "private, max-age=300")
+	return c.SendStream(r)
+}
@@ -4,6 +4,7 @@ import (
 	"backend/database"
 	"backend/model"
 	"backend/utils"
+	"errors"
 	"strings"
 	"time"
 
@@ -11,9 +12,15 @@ import (
 	"gorm.io/gorm"
 )
 
+const (
+	refreshTokenTTL = 7 * 24 * time.Hour
+	invitationTTL   = 72 * time.Hour
+)
+
 type LoginRequest struct {
 	Email    string `json:"email"`
 	Password string `json:"password"`
+	Device   string `json:"device"`
 }
 
 type RegisterRequest struct {
@@ -21,6 +28,14 @@ type RegisterRequest struct {
 	Password string `json:"password"`
 	FullName string `json:"full_name"`
 	Role     string `json:"role"`
+	Tenant   string `json:"tenant"`
+}
+
+type InvitationRequest struct {
+	Email    string `json:"email"`
+	FullName string `json:"full_name"`
+	Role     string `json:"role"`
+	TenantID uint   `json:"tenant_id"`
 }
 
 type AuthResponse struct {
@@ -38,23 +53,34 @@ func Register(c fiber.Ctx) error {
 		})
 	}
 
-	if req.Role == "" {
-		req.Role = "user"
+	if err := utils.ValidatePassword(req.Password); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": err.Error(),
+		})
 	}
 
-	validRoles := map[string]bool{"admin": true, "agent": true, "user": true}
-	if !validRoles[req.Role] {
+	if req.Role != "" && req.Role != string(model.RoleUser) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error":   true,
+			"message": "Only user accounts can be registered. Agent and admin accounts require an invitation.",
+		})
+	}
+
+	tenant, err := registrationTenant(c, req.Tenant)
+	if err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error":   true,
-			"message": "Invalid role. Must be admin, agent, or user",
+			"message": "Unknown or inactive tenant",
 		})
 	}
 
 	user := model.User{
+		TenantID:     tenant.ID,
 		Email:        req.Email,
 		PasswordHash: utils.GeneratePassword(req.Password),
 		FullName:     req.FullName,
-		Role:         model.Role(req.Role),
+		Role:         model.RoleUser,
 	}
 
 	if err := database.DB.Create(&user).Error; err != nil {
@@ -73,6 +99,24 @@ func Register(c fiber.Ctx) error {
 	})
 }
 
+// registrationTenant picks the tenant a customer signs up to: the "tenant"
+// slug in the body, else the X-Tenant header, else the default tenant.
+func registrationTenant(c fiber.Ctx, slug string) (model.Tenant, error) {
+	if slug == "" {
+		slug = c.Get("X-Tenant")
+	}
+
+	var tenant model.Tenant
+	query := database.DB.Where("is_active = ?", true)
+	if slug != "" {
+		query = query.Where("slug = ?", strings.TrimSpace(slug))
+	} else {
+		query = query.Where("id = ?", model.DefaultTenantID)
+	}
+	err := query.First(&tenant).Error
+	return tenant, err
+}
+
 func Login(c fiber.Ctx) error {
 	var req LoginRequest
 	if err := c.Bind().Body(&req); err != nil {
@@ -116,7 +160,30 @@ func Login(c fiber.Ctx) error {
 	}
 	utils.ResetFailedLogin(req.Email)
 
-	tokenDetails, err := utils.GenerateToken(user.ID, string(user.Role))
+	if !user.IsActive {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error":   true,
+			"message": "Account has been deactivated",
+		})
+	}
+
+	var tenant model.Tenant
+	if err := database.DB.First(&tenant, user.TenantID).Error; err != nil || !tenant.IsActive {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error":   true,
+			"message": "Tenant is not active",
+		})
+	}
+
+	session, err := createSession(c, user.ID, req.Device)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to create session",
+		})
+	}
+
+	tokenDetails, err := utils.GenerateToken(user, session.ID)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error":   true,
@@ -125,7 +192,19 @@ func Login(c fiber.Ctx) error {
 	}
 
 	refreshToken := utils.GenerateRefreshToken()
-	utils.StoreRefreshToken(user.ID, refreshToken, 7*24*time.Hour)
+	utils.StoreRefreshToken(user.ID, session.ID, refreshToken, refreshTokenTTL)
+
+	// The availability sweeper sets idle agents offline; logging in brings
+	// them back so routing picks them up. Busy and away are left alone.
+	if user.Role == model.RoleAgent && user.Availability == model.AvailabilityOffline {
+		result := database.DB.Model(&model.User{}).
+			Where("id = ? AND availability = ?", user.ID, model.AvailabilityOffline).
+			Update("availability", model.AvailabilityOnline)
+		if result.Error == nil && result.RowsAffected > 0 {
+			user.Availability = model.AvailabilityOnline
+			publishAvailability(user)
+		}
+	}
 
 	user.PasswordHash = ""
 
@@ -140,8 +219,16 @@ func Login(c fiber.Ctx) error {
 	})
 }
 
-func CreateUserByAdmin(c fiber.Ctx) error {
-	var req RegisterRequest
+func CreateInvitation(c fiber.Ctx) error {
+	adminID, ok := c.Locals("user_id").(uint)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   true,
+			"message": "Unauthorized",
+		})
+	}
+
+	var req InvitationRequest
 	if err := c.Bind().Body(&req); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error":   true,
@@ -149,6 +236,13 @@ func CreateUserByAdmin(c fiber.Ctx) error {
 		})
 	}
 
+	if req.Email == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Email is required",
+		})
+	}
+
 	validRoles := map[string]bool{"admin": true, "agent": true, "user": true}
 	if !validRoles[req.Role] {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
@@ -157,25 +251,148 @@ func CreateUserByAdmin(c fiber.Ctx) error {
 		})
 	}
 
+	var existing int64
+	database.DB.Model(&model.User{}).Where("email = ?", req.Email).Count(&existing)
+	if existing > 0 {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error":   true,
+			"message": "A user with this email already exists",
+		})
+	}
+
+	if superAdmin, _ := c.Locals("super_admin").(bool); !superAdmin || req.TenantID == 0 {
+		req.TenantID = currentTenantID(c)
+	}
+
+	token := utils.GenerateInviteToken()
+	invitation := model.Invitation{
+		TenantID:  req.TenantID,
+		Email:     req.Email,
+		FullName:  req.FullName,
+		Role:      model.Role(req.Role),
+		TokenHash: utils.HashToken(token),
+		InvitedBy: adminID,
+		ExpiresAt: time.Now().Add(invitationTTL),
+	}
+
+	if err := tenantDB(c).Create(&invitation).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to create invitation",
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"success": true,
+		"message": "Invitation created successfully",
+		"data": fiber.Map{
+			"invitation":   invitation,
+			"invite_token": token,
+		},
+	})
+}
+
+func GetInvitations(c fiber.Ctx) error {
+	var invitations []model.Invitation
+	if err := tenantDB(c).Order("id DESC").Find(&invitations).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to fetch invitations",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    invitations,
+		"total":   len(invitations),
+	})
+}
+
+func AcceptInvitation(c fiber.Ctx) error {
+	var req struct {
+		Token    string `json:"token"`
+		Password string `json:"password"`
+		FullName string `json:"full_name"`
+	}
+
+	if err := c.Bind().Body(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	if req.Token == "" || req.Password == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Token and password are required",
+		})
+	}
+
+	if err := utils.ValidatePassword(req.Password); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": err.Error(),
+		})
+	}
+
+	tx := database.DB.Begin()
+
+	var invitation model.Invitation
+	if err := tx.Where("token_hash = ? AND accepted_at IS NULL AND expires_at > ?", utils.HashToken(req.Token), time.Now()).
+		First(&invitation).Error; err != nil {
+		tx.Rollback()
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invitation is invalid or has expired",
+		})
+	}
+
+	// Claim the invitation conditionally so a token can only be redeemed once.
+	result := tx.Model(&model.Invitation{}).
+		Where("id = ? AND accepted_at IS NULL", invitation.ID).
+		Update("accepted_at", time.Now())
+	if result.Error != nil || result.RowsAffected == 0 {
+		tx.Rollback()
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invitation has already been used",
+		})
+	}
+
+	fullName := req.FullName
+	if fullName == "" {
+		fullName = invitation.FullName
+	}
+
 	user := model.User{
-		Email:        req.Email,
+		TenantID:     invitation.TenantID,
+		Email:        invitation.Email,
 		PasswordHash: utils.GeneratePassword(req.Password),
-		FullName:     req.FullName,
-		Role:         model.Role(req.Role),
+		FullName:     fullName,
+		Role:         invitation.Role,
 	}
 
-	if err := database.DB.Create(&user).Error; err != nil {
+	if err := tx.Create(&user).Error; err != nil {
+		tx.Rollback()
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error":   true,
 			"message": "Failed to create user: " + err.Error(),
 		})
 	}
 
+	if err := tx.Commit().Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to accept invitation",
+		})
+	}
+
 	user.PasswordHash = ""
 
 	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
 		"success": true,
-		"message": "User created successfully",
+		"message": "Invitation accepted successfully",
 		"data":    user,
 	})
 }
@@ -198,7 +415,31 @@ func Logout(c fiber.Ctx) error {
 	}
 	tokenString := parts[1]
 
-	utils.BlacklistToken(tokenString, 24*time.Hour)
+	claims, err := utils.ParseToken(tokenString)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid or expired token",
+		})
+	}
+
+	jti, _ := claims["jti"].(string)
+	exp, _ := claims["exp"].(float64)
+	if jti != "" {
+		if err := utils.Revocations.Revoke(jti, time.Unix(int64(exp), 0)); err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error":   true,
+				"message": "Failed to revoke token",
+			})
+		}
+	}
+
+	if sessionID, _ := claims["sid"].(string); sessionID != "" {
+		var session model.Session
+		if err := database.DB.Where("id = ? AND revoked_at IS NULL", sessionID).First(&session).Error; err == nil {
+			revokeSession(session)
+		}
+	}
 
 	return c.JSON(fiber.Map{
 		"success": true,
@@ -218,8 +459,82 @@ func RefreshToken(c fiber.Ctx) error {
 		})
 	}
 
+	if req.RefreshToken == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Refresh token is required",
+		})
+	}
+
+	userID, sessionID, err := utils.ConsumeRefreshToken(req.RefreshToken, refreshTokenTTL)
+	if errors.Is(err, utils.ErrRefreshTokenReused) {
+		revokeAllSessions(userID)
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   true,
+			"message": "Refresh token reuse detected. All sessions have been revoked.",
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid or expired refresh token",
+		})
+	}
+
+	var session model.Session
+	if err := database.DB.Where("id = ? AND user_id = ? AND revoked_at IS NULL", sessionID, userID).
+		First(&session).Error; err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   true,
+			"message": "Session has been revoked. Please login again.",
+		})
+	}
+
+	var user model.User
+	if err := database.DB.First(&user, userID).Error; err != nil || !user.IsActive {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   true,
+			"message": "User not found or deactivated",
+		})
+	}
+
+	var tenant model.Tenant
+	if err := database.DB.First(&tenant, user.TenantID).Error; err != nil || !tenant.IsActive {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error":   true,
+			"message": "Tenant is not active",
+		})
+	}
+
+	now := time.Now()
+	database.DB.Model(&session).Updates(map[string]interface{}{
+		"last_used_at": now,
+		"expires_at":   now.Add(refreshTokenTTL),
+		"ip_address":   c.IP(),
+	})
+
+	tokenDetails, err := utils.GenerateToken(user, session.ID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to generate token",
+		})
+	}
+
+	refreshToken := utils.GenerateRefreshToken()
+	if err := utils.StoreRefreshToken(user.ID, session.ID, refreshToken, refreshTokenTTL); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to store refresh token",
+		})
+	}
+
 	return c.JSON(fiber.Map{
 		"success": true,
-		"message": "Refresh token endpoint",
+		"data": fiber.Map{
+			"access_token":  tokenDetails.Token,
+			"refresh_token": refreshToken,
+			"expires_in":    tokenDetails.ExpiresAt.Unix(),
+		},
 	})
 }
@@ -0,0 +1,214 @@
+package controller
+
+import (
+	"backend/database"
+	"backend/model"
+	"backend/routing"
+	"backend/utils"
+	"log"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// availabilityIdleTimeout is how long an agent may go without using any
+// session before they are set offline automatically.
+const availabilityIdleTimeout = 15 * time.Minute
+
+// agentCapacity describes an agent's chat load. Remaining is -1 when the
+// agent has no limit.
+func agentCapacity(agent model.User) fiber.Map {
+	limit, open := routing.Capacity(database.DB, agent)
+
+	remaining := int64(-1)
+	if limit > 0 {
+		remaining = max(int64(limit)-open, 0)
+	}
+
+	return fiber.Map{
+		"max_concurrent": limit,
+		"active":         open,
+		"remaining":      remaining,
+	}
+}
+
+func GetMyAvailability(c fiber.Ctx) error {
+	userID, _ := c.Locals("user_id").(uint)
+
+	var agent model.User
+	if err := tenantDB(c).First(&agent, userID).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "User not found",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data": fiber.Map{
+			"availability": agent.Availability,
+			"capacity":     agentCapacity(agent),
+		},
+	})
+}
+
+func UpdateMyAvailability(c fiber.Ctx) error {
+	userID, _ := c.Locals("user_id").(uint)
+
+	var req struct {
+		Availability string `json:"availability"`
+	}
+
+	if err := c.Bind().Body(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	if !model.IsValidAvailability(req.Availability) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid availability. Must be online, busy, away, or offline",
+		})
+	}
+
+	var agent model.User
+	if err := tenantDB(c).First(&agent, userID).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "User not found",
+		})
+	}
+
+	if err := tenantDB(c).Model(&agent).Update("availability", req.Availability).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to update availability",
+		})
+	}
+	agent.Availability = req.Availability
+
+	publishAvailability(agent)
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Availability updated successfully",
+		"data": fiber.Map{
+			"availability": agent.Availability,
+			"capacity":     agentCapacity(agent),
+		},
+	})
+}
+
+func GetAgentAvailability(c fiber.Ctx) error {
+	var agents []model.User
+	if err := tenantDB(c).Where("role = ? AND is_active = ?", model.RoleAgent, true).
+		Order("id ASC").
+		Find(&agents).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to fetch agents",
+		})
+	}
+
+	data := []fiber.Map{}
+	for _, agent := range agents {
+		data = append(data, fiber.Map{
+			"id":           agent.ID,
+			"full_name":    agent.FullName,
+			"email":        agent.Email,
+			"availability": agent.Availability,
+			"capacity":     agentCapacity(agent),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    data,
+	})
+}
+
+func UpdateAgentCapacity(c fiber.Ctx) error {
+	var req struct {
+		MaxConcurrent *int `json:"max_concurrent"`
+	}
+
+	if err := c.Bind().Body(&req); err != nil || req.MaxConcurrent == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "max_concurrent is required",
+		})
+	}
+
+	if *req.MaxConcurrent < 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "max_concurrent cannot be negative",
+		})
+	}
+
+	var agent model.User
+	if err := tenantDB(c).Where("role = ?", model.RoleAgent).First(&agent, c.Params("id")).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Agent not found",
+		})
+	}
+
+	if err := tenantDB(c).Model(&agent).Update("max_concurrent", *req.MaxConcurrent).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to update capacity",
+		})
+	}
+	agent.MaxConcurrent = *req.MaxConcurrent
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Capacity updated successfully",
+		"data": fiber.Map{
+			"id":       agent.ID,
+			"capacity": agentCapacity(agent),
+		},
+	})
+}
+
+func publishAvailability(agent model.User) {
+	utils.PublishEvent(utils.PresenceTopic(agent.TenantID), "availability", fiber.Map{
+		"user_id":      agent.ID,
+		"availability": agent.Availability,
+	})
+}
+
+// StartAvailabilitySweeper sets agents offline once none of their sessions has
+// been used for availabilityIdleTimeout.
+func StartAvailabilitySweeper(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	go func() {
+		for range ticker.C {
+			var idle []model.User
+			err := database.DB.Where("role = ? AND availability <> ?", model.RoleAgent, model.AvailabilityOffline).
+				Where("id NOT IN (?)", database.DB.Model(&model.Session{}).
+					Select("user_id").
+					Where("revoked_at IS NULL AND last_used_at > ?", time.Now().Add(-availabilityIdleTimeout))).
+				Limit(500).
+				Find(&idle).Error
+			if err != nil {
+				log.Printf("availability sweeper: %v", err)
+				continue
+			}
+
+			for _, agent := range idle {
+				result := database.DB.Model(&model.User{}).
+					Where("id = ? AND availability = ?", agent.ID, agent.Availability).
+					Update("availability", model.AvailabilityOffline)
+				if result.Error != nil || result.RowsAffected == 0 {
+					continue
+				}
+				agent.Availability = model.AvailabilityOffline
+				publishAvailability(agent)
+			}
+		}
+	}()
+}
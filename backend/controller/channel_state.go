@@ -0,0 +1,182 @@
+package controller
+
+import (
+	"backend/database"
+	"backend/model"
+	"backend/utils"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+	"gorm.io/gorm"
+)
+
+var errChannelConflict = errors.New("channel was changed concurrently")
+
+// transitionChannel moves a channel to a new status through the state machine.
+// The update is conditional on the status that was read, so a concurrent
+// change yields errChannelConflict instead of being overwritten.
+func transitionChannel(db *gorm.DB, channel *model.Channel, to string, updates map[string]interface{}) error {
+	if err := model.CanTransition(channel.Status, to); err != nil {
+		return err
+	}
+
+	if updates == nil {
+		updates = map[string]interface{}{}
+	}
+	updates["status"] = to
+	updates["updated_at"] = time.Now()
+	if _, ok := updates["snoozed_until"]; !ok && to != model.ChannelSnoozed {
+		updates["snoozed_until"] = nil
+	}
+
+	result := db.Model(&model.Channel{}).
+		Where("id = ? AND status = ?", channel.ID, channel.Status).
+		Updates(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errChannelConflict
+	}
+
+	channel.Status = to
+	return nil
+}
+
+func transitionErrorResponse(c fiber.Ctx, err error) error {
+	var transitionErr *model.TransitionError
+	if errors.As(err, &transitionErr) {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error":   true,
+			"message": transitionErr.Error(),
+		})
+	}
+	if errors.Is(err, errChannelConflict) {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error":   true,
+			"message": "Channel was changed by someone else. Please retry.",
+		})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"error":   true,
+		"message": "Failed to update channel status",
+	})
+}
+
+func UpdateChannelStatus(c fiber.Ctx) error {
+	userID, _ := c.Locals("user_id").(uint)
+	role, _ := c.Locals("role").(string)
+
+	var req struct {
+		Status       string     `json:"status"`
+		SnoozedUntil *time.Time `json:"snoozed_until"`
+	}
+
+	if err := c.Bind().Body(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	if !model.IsValidChannelStatus(req.Status) || req.Status == model.ChannelOpen {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid status. Must be assigned, pending, snoozed, closed, or reopened",
+		})
+	}
+
+	updates := map[string]interface{}{}
+	if req.Status == model.ChannelSnoozed {
+		if req.SnoozedUntil == nil || !req.SnoozedUntil.After(time.Now()) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   true,
+				"message": "snoozed_until must be a future time",
+			})
+		}
+		updates["snoozed_until"] = *req.SnoozedUntil
+	}
+
+	var channel model.Channel
+	query := tenantDB(c)
+	if role == "agent" {
+		query = query.Where("assigned_agent_id = ?", userID)
+	}
+
+	if err := query.First(&channel, c.Params("id")).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Channel not found or not assigned to you",
+		})
+	}
+
+	needsAgent := req.Status == model.ChannelAssigned || req.Status == model.ChannelPending || req.Status == model.ChannelSnoozed
+	if needsAgent && channel.AssignedAgentID == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Channel has no agent. Assign it first.",
+		})
+	}
+
+	fromStatus := channel.Status
+	if err := transitionChannel(tenantDB(c), &channel, req.Status, updates); err != nil {
+		return transitionErrorResponse(c, err)
+	}
+
+	recordTransition(tenantDB(c), channel, fromStatus, userID)
+	refreshChannelState(channel)
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Channel status updated successfully",
+		"data": fiber.Map{
+			"channel_id":    channel.ID,
+			"status":        channel.Status,
+			"snoozed_until": req.SnoozedUntil,
+		},
+	})
+}
+
+func refreshChannelState(channel model.Channel) {
+	invalidateChannelCache(channel.ID)
+	invalidateAgentConversationsCache(channel.AssignedAgentID)
+	invalidateUserConversationsCache(channel.CustomerID)
+	if channel.AssignedAgentID == 0 {
+		invalidateAvailableChannelsCache(channel.TenantID)
+	}
+}
+
+func invalidateAvailableChannelsCache(tenantID uint) {
+	utils.DeleteCache(fmt.Sprintf("channels:available:%d", tenantID))
+
+	// Tag-filtered listings are cached under their own keys.
+	utils.DeleteCachePattern(fmt.Sprintf("channels:available:%d:*", tenantID))
+}
+
+// StartSnoozeWaker returns snoozed conversations to their agent once the
+// snooze expires.
+func StartSnoozeWaker(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	go func() {
+		for range ticker.C {
+			var due []model.Channel
+			if err := database.DB.Where("status = ? AND snoozed_until <= ?", model.ChannelSnoozed, time.Now()).
+				Limit(200).
+				Find(&due).Error; err != nil {
+				log.Printf("snooze waker: %v", err)
+				continue
+			}
+
+			for _, channel := range due {
+				if err := transitionChannel(database.DB, &channel, model.ChannelAssigned, nil); err != nil {
+					continue
+				}
+				recordTransition(database.DB, channel, model.ChannelSnoozed, 0)
+				refreshChannelState(channel)
+			}
+		}
+	}()
+}
@@ -1,16 +1,21 @@
 package controller
 
 import (
-	"backend/config"
 	"backend/database"
 	"backend/model"
+	"backend/routing"
+	"backend/sla"
 	"backend/utils"
-	"encoding/json"
+	"errors"
 	"fmt"
+	"mime/multipart"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v3"
+	"gorm.io/gorm"
 )
 
 func GetAgentConversations(c fiber.Ctx) error {
@@ -31,7 +36,7 @@ func GetAgentConversations(c fiber.Ctx) error {
 	}
 
 	var agent model.User
-	if err := database.DB.First(&agent, userID).Error; err != nil || agent.Role != model.RoleAgent {
+	if err := tenantDB(c).First(&agent, userID).Error; err != nil || agent.Role != model.RoleAgent {
 		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
 			"error":   true,
 			"message": "User is not an agent",
@@ -41,11 +46,20 @@ func GetAgentConversations(c fiber.Ctx) error {
 	status := c.Query("status", "all")
 	limit := c.Query("limit", "10")
 	offset := c.Query("offset", "0")
+	tags := parseTagFilter(c.Query("tag"))
+	slaFilter := c.Query("sla")
+
+	if slaFilter != "" && slaFilter != "breaching_soon" && slaFilter != "breached" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid sla filter. Must be breaching_soon or breached",
+		})
+	}
 
 	limitInt, _ := strconv.Atoi(limit)
 	offsetInt, _ := strconv.Atoi(offset)
 
-	cacheKey := fmt.Sprintf("agent:conversations:%d:%s:%s:%s", userID, status, limit, offset)
+	cacheKey := fmt.Sprintf("agent:conversations:%d:%s:%s:%s:%s:%s", userID, status, limit, offset, strings.Join(tags, ","), slaFilter)
 
 	var cachedResponse fiber.Map
 	err := utils.GetCache(cacheKey, &cachedResponse)
@@ -54,12 +68,13 @@ func GetAgentConversations(c fiber.Ctx) error {
 	}
 
 	var channels []model.Channel
-	query := database.DB.Model(&model.Channel{}).
+	query := tenantDB(c).Model(&model.Channel{}).
 		Where("assigned_agent_id = ?", userID)
 
 	if status != "all" {
 		query = query.Where("status = ?", status)
 	}
+	query = withSLAFilter(withTags(query, tags), slaFilter)
 
 	query = query.Limit(limitInt).Offset(offsetInt).
 		Order("id DESC")
@@ -72,16 +87,18 @@ func GetAgentConversations(c fiber.Ctx) error {
 	}
 
 	var total int64
-	countQuery := database.DB.Model(&model.Channel{}).Where("assigned_agent_id = ?", userID)
+	countQuery := tenantDB(c).Model(&model.Channel{}).Where("assigned_agent_id = ?", userID)
 	if status != "all" {
 		countQuery = countQuery.Where("status = ?", status)
 	}
-	countQuery.Count(&total)
+	withSLAFilter(withTags(countQuery, tags), slaFilter).Count(&total)
+
+	tagNames := channelTagNames(channelIDs(channels))
 
 	var responseData []fiber.Map
 	for _, channel := range channels {
 		var customer model.User
-		if err := database.DB.Select("id", "email", "full_name").First(&customer, channel.CustomerID).Error; err != nil {
+		if err := tenantDB(c).Select("id", "email", "full_name").First(&customer, channel.CustomerID).Error; err != nil {
 			customer = model.User{
 				ID:       channel.CustomerID,
 				FullName: "Unknown",
@@ -90,7 +107,7 @@ func GetAgentConversations(c fiber.Ctx) error {
 		}
 
 		lastMessage := getLastMessageFromCacheOrDB(channel.ID)
-		unreadCount := getUnreadCountFromCacheOrDB(channel.ID, "customer")
+		unreadCount := getUnreadCountFromCacheOrDB(channel.ID, userID, "customer")
 
 		responseData = append(responseData, fiber.Map{
 			"id":                channel.ID,
@@ -107,6 +124,99 @@ func GetAgentConversations(c fiber.Ctx) error {
 				"created_at":  lastMessage.CreatedAt,
 			},
 			"unread_count": unreadCount,
+			"tags":         tagNames[channel.ID],
+			"sla": fiber.Map{
+				"first_response_due_at":      channel.FirstResponseDueAt,
+				"first_responded_at":         channel.FirstRespondedAt,
+				"first_response_breached_at": channel.FirstResponseBreachedAt,
+				"resolution_due_at":          channel.ResolutionDueAt,
+				"resolution_breached_at":     channel.ResolutionBreachedAt,
+			},
+		})
+	}
+
+	response := fiber.Map{
+		"success": true,
+		"data":    responseData,
+		"pagination": fiber.Map{
+			"total":  total,
+			"limit":  limitInt,
+			"offset": offsetInt,
+		},
+	}
+
+	utils.SetCache(cacheKey, response, 30*time.Second)
+
+	return c.JSON(response)
+}
+
+func GetUserConversations(c fiber.Ctx) error {
+	userID, ok := c.Locals("user_id").(uint)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   true,
+			"message": "Unauthorized - User ID not found",
+		})
+	}
+
+	status := c.Query("status", "all")
+	limit := c.Query("limit", "10")
+	offset := c.Query("offset", "0")
+
+	limitInt, _ := strconv.Atoi(limit)
+	offsetInt, _ := strconv.Atoi(offset)
+	if limitInt <= 0 || limitInt > 100 {
+		limitInt = 10
+	}
+	if offsetInt < 0 {
+		offsetInt = 0
+	}
+
+	cacheKey := fmt.Sprintf("user:conversations:%d:%s:%d:%d", userID, status, limitInt, offsetInt)
+
+	var cachedResponse fiber.Map
+	err := utils.GetCache(cacheKey, &cachedResponse)
+	if err == nil && cachedResponse != nil {
+		return c.JSON(cachedResponse)
+	}
+
+	query := tenantDB(c).Model(&model.Channel{}).Where("customer_id = ?", userID)
+	if status != "all" {
+		query = query.Where("status = ?", status)
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var channels []model.Channel
+	if err := query.Order("updated_at DESC").
+		Limit(limitInt).
+		Offset(offsetInt).
+		Find(&channels).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to fetch channels",
+		})
+	}
+
+	responseData := []fiber.Map{}
+	for _, channel := range channels {
+		lastMessage := getLastMessageFromCacheOrDB(channel.ID)
+		unreadCount := getUnreadCountFromCacheOrDB(channel.ID, userID, "agent")
+
+		responseData = append(responseData, fiber.Map{
+			"id":                channel.ID,
+			"status":            channel.Status,
+			"assigned_agent_id": channel.AssignedAgentID,
+			"created_at":        channel.CreatedAt,
+			"updated_at":        channel.UpdatedAt,
+			"last_message": fiber.Map{
+				"id":          lastMessage.ID,
+				"message":     lastMessage.Message,
+				"sender_type": lastMessage.SenderType,
+				"created_at":  lastMessage.CreatedAt,
+			},
+			"unread_count": unreadCount,
 		})
 	}
 
@@ -137,19 +247,31 @@ func GetChannelByID(c fiber.Ctx) error {
 		})
 	}
 
-	cacheKey := fmt.Sprintf("channel:%s:role:%s:user:%d", channelID, role, userID)
+	page, err := parseMessagePage(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": err.Error(),
+		})
+	}
+
+	cacheKey := fmt.Sprintf("channel:%s:role:%s:user:%d:page:%d:%d:%d:%s",
+		channelID, role, userID, page.BeforeID, page.AfterID, page.Limit, page.Order)
 
 	var cachedResponse fiber.Map
-	err := utils.GetCache(cacheKey, &cachedResponse)
+	err = utils.GetCache(cacheKey, &cachedResponse)
 	if err == nil && cachedResponse != nil {
 		return c.JSON(cachedResponse)
 	}
 
 	var channel model.Channel
-	query := database.DB
+	query := tenantDB(c)
 
-	if role == "agent" {
+	switch role {
+	case "agent":
 		query = query.Where("assigned_agent_id = ?", userID)
+	case "user":
+		query = query.Where("customer_id = ?", userID)
 	}
 
 	if err := query.First(&channel, channelID).Error; err != nil {
@@ -159,30 +281,31 @@ func GetChannelByID(c fiber.Ctx) error {
 		})
 	}
 
-	var messages []model.Message
-	database.DB.Where("conversation_id = ?", channel.ID).
-		Order("id ASC").
-		Find(&messages)
+	messages, cursor := loadMessagePage(tenantDB(c), channel.ID, page)
 
 	var customer model.User
-	database.DB.Select("id", "email", "full_name").First(&customer, channel.CustomerID)
-
-	if role == "agent" {
-		database.DB.Model(&model.Message{}).
-			Where("conversation_id = ? AND sender_type = ? AND is_read = ?",
-				channel.ID, "customer", false).
-			Update("is_read", true)
-
-		utils.DeleteCache(fmt.Sprintf("unread:channel:%s:agent", channelID))
-	} else if role == "user" {
-		database.DB.Model(&model.Message{}).
-			Where("conversation_id = ? AND sender_type = ? AND is_read = ?",
-				channel.ID, "agent", false).
-			Update("is_read", true)
-
-		utils.DeleteCache(fmt.Sprintf("unread:channel:%s:user", channelID))
+	tenantDB(c).Select("id", "email", "full_name").First(&customer, channel.CustomerID)
+
+	// Internal notes, tags and the event timeline are for staff only.
+	timeline := []model.ConversationEvent{}
+	notes := []model.Note{}
+	tags := []string{}
+	if role == "agent" || role == "admin" {
+		tags = channelTagNames([]uint{channel.ID})[channel.ID]
+		tenantDB(c).Where("channel_id = ?", channel.ID).
+			Order("id ASC").
+			Find(&timeline)
+		tenantDB(c).Where("channel_id = ?", channel.ID).
+			Order("id ASC").
+			Find(&notes)
 	}
 
+	// Reading is explicit through POST .../read; here we only report where
+	// each participant's cursor is.
+	readCursors := []model.ReadCursor{}
+	tenantDB(c).Where("channel_id = ?", channel.ID).Find(&readCursors)
+	withReadFlags(messages, channel, readCursors)
+
 	response := fiber.Map{
 		"success": true,
 		"data": fiber.Map{
@@ -194,10 +317,16 @@ func GetChannelByID(c fiber.Ctx) error {
 				"customer_email":    customer.Email,
 				"status":            channel.Status,
 				"assigned_agent_id": channel.AssignedAgentID,
+				"team_id":           channel.TeamID,
+				"tags":              tags,
 				"created_at":        channel.CreatedAt,
 				"updated_at":        channel.UpdatedAt,
 			},
 			"messages": messages,
+			"cursor":   cursor,
+			"read":     readCursors,
+			"timeline": timeline,
+			"notes":    notes,
 		},
 	}
 
@@ -232,32 +361,70 @@ func AssignChannel(c fiber.Ctx) error {
 	}
 
 	var channel model.Channel
-	if err := database.DB.First(&channel, channelID).Error; err != nil {
+	if err := tenantDB(c).First(&channel, channelID).Error; err != nil {
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
 			"error":   true,
 			"message": "Channel not found",
 		})
 	}
 
-	updates := map[string]interface{}{
-		"assigned_agent_id": agentID,
-		"status":            "assigned",
-		"updated_at":        time.Now(),
+	if err := model.CanTransition(channel.Status, model.ChannelAssigned); err != nil {
+		return transitionErrorResponse(c, err)
+	}
+
+	if role == "agent" && !model.InTeamQueue(channel, agentTeamIDs(agentID)) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error":   true,
+			"message": "This channel is queued for a team you are not in",
+		})
 	}
 
-	if err := database.DB.Model(&channel).Updates(updates).Error; err != nil {
+	// Agents are held to their capacity; the check and the claim run in one
+	// transaction so two simultaneous claims cannot both slip under the limit.
+	// Only a queued, unassigned channel can be claimed, so only one of several
+	// agents racing for the same channel wins.
+	limit, open, err := routing.Claim(tenantDB(c), channel.ID, agentID, role == "agent")
+	if errors.Is(err, routing.ErrAtCapacity) {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error":   true,
+			"message": "You are at your concurrent chat capacity",
+			"data": fiber.Map{
+				"max_concurrent": limit,
+				"active":         open,
+			},
+		})
+	}
+	if errors.Is(err, routing.ErrAlreadyAssigned) {
+		tenantDB(c).First(&channel, channel.ID)
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error":   true,
+			"message": "Channel has already been claimed",
+			"data": fiber.Map{
+				"channel_id":        channel.ID,
+				"assigned_agent_id": channel.AssignedAgentID,
+				"status":            channel.Status,
+			},
+		})
+	}
+	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error":   true,
 			"message": "Failed to assign channel",
 		})
 	}
 
+	recordEvent(tenantDB(c), channel, model.ConversationEvent{
+		Type:       model.EventAssigned,
+		ActorID:    agentID,
+		FromStatus: channel.Status,
+		ToStatus:   model.ChannelAssigned,
+		ToAgentID:  agentID,
+	})
+
 	invalidateChannelCache(channel.ID)
 	invalidateAgentConversationsCache(agentID)
-
-	if role == "admin" && channel.AssignedAgentID != agentID {
-		invalidateAgentConversationsCache(channel.AssignedAgentID)
-	}
+	invalidateUserConversationsCache(channel.CustomerID)
+	invalidateAvailableChannelsCache(channel.TenantID)
 
 	return c.JSON(fiber.Map{
 		"success": true,
@@ -265,7 +432,107 @@ func AssignChannel(c fiber.Ctx) error {
 		"data": fiber.Map{
 			"channel_id":        channel.ID,
 			"assigned_agent_id": agentID,
-			"status":            "assigned",
+			"status":            model.ChannelAssigned,
+		},
+	})
+}
+
+func ReassignChannel(c fiber.Ctx) error {
+	adminID, ok := c.Locals("user_id").(uint)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   true,
+			"message": "Unauthorized",
+		})
+	}
+
+	var req struct {
+		AgentID uint   `json:"agent_id"`
+		Reason  string `json:"reason"`
+	}
+
+	if err := c.Bind().Body(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	if req.AgentID == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "agent_id is required",
+		})
+	}
+
+	var channel model.Channel
+	if err := tenantDB(c).First(&channel, c.Params("id")).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Channel not found",
+		})
+	}
+
+	if err := model.CanTransition(channel.Status, model.ChannelAssigned); err != nil {
+		return transitionErrorResponse(c, err)
+	}
+
+	var agent model.User
+	if err := tenantDB(c).Where("role = ? AND is_active = ?", model.RoleAgent, true).
+		First(&agent, req.AgentID).Error; err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Target agent not found",
+		})
+	}
+
+	previousAgentID := channel.AssignedAgentID
+
+	err := moveChannel(tenantDB(c), &channel, agent.ID, model.ChannelAssigned, nil, model.ChannelAssignment{
+		Type:       model.AssignmentReassign,
+		AssignedBy: adminID,
+		Reason:     req.Reason,
+	})
+
+	if errors.Is(err, errChannelConflict) {
+		tenantDB(c).First(&channel, channel.ID)
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error":   true,
+			"message": "Channel was changed by someone else. Please retry.",
+			"data": fiber.Map{
+				"channel_id":        channel.ID,
+				"assigned_agent_id": channel.AssignedAgentID,
+				"status":            channel.Status,
+			},
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to reassign channel",
+		})
+	}
+
+	invalidateChannelCache(channel.ID)
+	invalidateAgentConversationsCache(agent.ID)
+	invalidateAgentConversationsCache(previousAgentID)
+	invalidateUserConversationsCache(channel.CustomerID)
+	invalidateAvailableChannelsCache(channel.TenantID)
+
+	utils.PublishEvent(utils.ChannelTopic(channel.ID), "reassigned", fiber.Map{
+		"channel_id":        channel.ID,
+		"assigned_agent_id": agent.ID,
+		"status":            model.ChannelAssigned,
+	})
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Channel reassigned successfully",
+		"data": fiber.Map{
+			"channel_id":        channel.ID,
+			"from_agent_id":     previousAgentID,
+			"assigned_agent_id": agent.ID,
+			"status":            model.ChannelAssigned,
 		},
 	})
 }
@@ -283,7 +550,7 @@ func CloseChannel(c fiber.Ctx) error {
 	}
 
 	var channel model.Channel
-	query := database.DB
+	query := tenantDB(c)
 
 	if role == "agent" {
 		query = query.Where("assigned_agent_id = ?", userID)
@@ -296,18 +563,13 @@ func CloseChannel(c fiber.Ctx) error {
 		})
 	}
 
-	if err := database.DB.Model(&channel).Update("status", "closed").Error; err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error":   true,
-			"message": "Failed to close channel",
-		})
+	fromStatus := channel.Status
+	if err := transitionChannel(tenantDB(c), &channel, model.ChannelClosed, nil); err != nil {
+		return transitionErrorResponse(c, err)
 	}
 
-	invalidateChannelCache(channel.ID)
-	invalidateAgentConversationsCache(channel.AssignedAgentID)
-	if channel.CustomerID > 0 {
-		invalidateUserConversationsCache(channel.CustomerID)
-	}
+	recordTransition(tenantDB(c), channel, fromStatus, userID)
+	refreshChannelState(channel)
 
 	return c.JSON(fiber.Map{
 		"success": true,
@@ -335,7 +597,10 @@ func SendMessage(c fiber.Ctx) error {
 	}
 
 	var req struct {
-		Message string `json:"message"`
+		Message  string `json:"message" form:"message"`
+		ReplyID  uint   `json:"reply_id" form:"reply_id"`
+		Shortcut string `json:"shortcut" form:"shortcut"`
+		Render   bool   `json:"render" form:"render"`
 	}
 
 	if err := c.Bind().Body(&req); err != nil {
@@ -345,7 +610,14 @@ func SendMessage(c fiber.Ctx) error {
 		})
 	}
 
-	if req.Message == "" {
+	// Files arrive as multipart "files" parts alongside the text fields.
+	var files []*multipart.FileHeader
+	if form, err := c.MultipartForm(); err == nil {
+		files = form.File["files"]
+	}
+
+	useReply := role != "user" && (req.ReplyID != 0 || req.Shortcut != "")
+	if req.Message == "" && !useReply && len(files) == 0 {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error":   true,
 			"message": "Message is required",
@@ -353,27 +625,18 @@ func SendMessage(c fiber.Ctx) error {
 	}
 
 	var channel model.Channel
-	if err := database.DB.First(&channel, channelID).Error; err != nil {
+	if err := tenantDB(c).First(&channel, channelID).Error; err != nil {
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
 			"error":   true,
 			"message": "Channel not found",
 		})
 	}
 
-	if role == "agent" {
-		if channel.AssignedAgentID != userID {
-			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
-				"error":   true,
-				"message": "Access denied. This channel is not assigned to you.",
-			})
-		}
-	} else if role == "user" {
-		if channel.CustomerID != userID {
-			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
-				"error":   true,
-				"message": "Access denied. This is not your channel.",
-			})
-		}
+	if allowed, message := canAccessChannel(channel, userID, role); !allowed {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error":   true,
+			"message": message,
+		})
 	}
 
 	senderType := "agent"
@@ -381,21 +644,109 @@ func SendMessage(c fiber.Ctx) error {
 		senderType = "customer"
 	}
 
+	// Agent replies may come from a saved reply, whose template variables are
+	// filled in. Typed text is sent as is unless the agent opts in with render,
+	// so pasted code or literal braces are never rewritten.
+	if senderType == "agent" {
+		if useReply {
+			reply, ok := findReplyForSend(c, req.ReplyID, req.Shortcut)
+			if !ok {
+				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+					"error":   true,
+					"message": "Saved reply not found",
+				})
+			}
+			req.Message = reply.Body
+		}
+		if (useReply || req.Render) && strings.Contains(req.Message, "{{") {
+			req.Message = renderReply(req.Message, replyVariables(tenantDB(c), channel, userID))
+		}
+	}
+
+	// Customer replies bring the conversation back to the agent; agents cannot
+	// write into a closed conversation.
+	nextStatus := ""
+	switch {
+	case senderType == "customer" && channel.Status == model.ChannelClosed:
+		nextStatus = model.ChannelReopened
+	case senderType == "customer" && (channel.Status == model.ChannelPending || channel.Status == model.ChannelSnoozed):
+		nextStatus = model.ChannelAssigned
+	case senderType == "agent" && channel.Status == model.ChannelClosed:
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error":   true,
+			"message": "Channel is closed. Reopen it before replying.",
+		})
+	case senderType == "agent" && channel.Status == model.ChannelReopened && channel.AssignedAgentID != 0:
+		nextStatus = model.ChannelAssigned
+	}
+
+	if nextStatus != "" {
+		fromStatus := channel.Status
+		err := transitionChannel(tenantDB(c), &channel, nextStatus, nil)
+		if err != nil && !errors.Is(err, errChannelConflict) {
+			return transitionErrorResponse(c, err)
+		}
+		if err == nil {
+			recordTransition(tenantDB(c), channel, fromStatus, userID)
+			if nextStatus == model.ChannelReopened {
+				restartResolutionClock(tenantDB(c), &channel, time.Now())
+			}
+		}
+		if nextStatus == model.ChannelReopened && channel.AssignedAgentID == 0 {
+			invalidateAvailableChannelsCache(channel.TenantID)
+		}
+	}
+
+	attachments, err := storeAttachments(channel, files)
+	if err != nil {
+		var attachErr *attachmentError
+		if errors.As(err, &attachErr) {
+			return c.Status(attachErr.status).JSON(fiber.Map{
+				"error":   true,
+				"message": attachErr.message,
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to store attachments",
+		})
+	}
+
 	message := model.Message{
 		ConversationID: channel.ID,
 		SenderType:     senderType,
 		Message:        req.Message,
-		IsRead:         false,
 	}
 
-	if err := database.DB.Create(&message).Error; err != nil {
+	err = tenantDB(c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&message).Error; err != nil {
+			return err
+		}
+		for i := range attachments {
+			attachments[i].MessageID = message.ID
+		}
+		if len(attachments) > 0 {
+			return tx.Create(&attachments).Error
+		}
+		return nil
+	})
+	if err != nil {
+		deleteStoredAttachments(attachments)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error":   true,
 			"message": "Failed to send message",
 		})
 	}
+	message.Attachments = attachments
+
+	// Senders have read everything up to their own message.
+	markRead(tenantDB(c), channel, userID, message.ID)
 
-	database.DB.Model(&channel).Update("updated_at", time.Now())
+	if senderType == "agent" {
+		recordFirstResponse(tenantDB(c), &channel, message.CreatedAt)
+	}
+
+	tenantDB(c).Model(&channel).Update("updated_at", time.Now())
 
 	invalidateChannelCache(channel.ID)
 	invalidateLastMessageCache(channel.ID)
@@ -422,16 +773,42 @@ func GetAvailableChannels(c fiber.Ctx) error {
 		})
 	}
 
-	cacheKey := "channels:available"
+	tags := parseTagFilter(c.Query("tag"))
+
+	// Agents only see the tenant-wide queue and their own teams' queues.
+	var teamIDs []uint
+	if role == "agent" {
+		userID, _ := c.Locals("user_id").(uint)
+		teamIDs = agentTeamIDs(userID)
+		slices.Sort(teamIDs)
+	}
+
+	cacheKey := fmt.Sprintf("channels:available:%d", currentTenantID(c))
+	if len(tags) > 0 {
+		cacheKey += ":tag:" + strings.Join(tags, ",")
+	}
+	if role == "agent" {
+		cacheKey += fmt.Sprintf(":teams:%v", teamIDs)
+	}
+
+	// A super admin's listing spans every tenant, so it must never land in
+	// (or be served from) a tenant's cache key.
+	superAdmin, _ := c.Locals("super_admin").(bool)
 
 	var cachedResponse fiber.Map
-	err := utils.GetCache(cacheKey, &cachedResponse)
-	if err == nil && cachedResponse != nil {
-		return c.JSON(cachedResponse)
+	if !superAdmin {
+		err := utils.GetCache(cacheKey, &cachedResponse)
+		if err == nil && cachedResponse != nil {
+			return c.JSON(withCallerCapacity(c, cachedResponse))
+		}
 	}
 
 	var channels []model.Channel
-	if err := database.DB.Where("status = ? AND assigned_agent_id = ?", "open", 0).
+	query := tenantDB(c).Where("status IN ? AND assigned_agent_id = ?", model.ClaimableStatuses, 0)
+	if role == "agent" {
+		query = model.InTeamQueues(query, "team_id", teamIDs)
+	}
+	if err := withTags(query, tags).
 		Order("id ASC").
 		Find(&channels).Error; err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
@@ -440,10 +817,12 @@ func GetAvailableChannels(c fiber.Ctx) error {
 		})
 	}
 
+	tagNames := channelTagNames(channelIDs(channels))
+
 	var responseData []fiber.Map
 	for _, channel := range channels {
 		var customer model.User
-		database.DB.Select("id", "email", "full_name").First(&customer, channel.CustomerID)
+		tenantDB(c).Select("id", "email", "full_name").First(&customer, channel.CustomerID)
 
 		responseData = append(responseData, fiber.Map{
 			"id":             channel.ID,
@@ -452,6 +831,8 @@ func GetAvailableChannels(c fiber.Ctx) error {
 			"customer_name":  customer.FullName,
 			"customer_email": customer.Email,
 			"status":         channel.Status,
+			"team_id":        channel.TeamID,
+			"tags":           tagNames[channel.ID],
 		})
 	}
 
@@ -460,9 +841,33 @@ func GetAvailableChannels(c fiber.Ctx) error {
 		"data":    responseData,
 	}
 
-	utils.SetCache(cacheKey, response, 15*time.Second)
+	if !superAdmin {
+		utils.SetCache(cacheKey, response, 15*time.Second)
+	}
 
-	return c.JSON(response)
+	return c.JSON(withCallerCapacity(c, response))
+}
+
+// withCallerCapacity adds the calling agent's own capacity to a shared,
+// cached listing without caching it.
+func withCallerCapacity(c fiber.Ctx, response fiber.Map) fiber.Map {
+	role, _ := c.Locals("role").(string)
+	if role != "agent" {
+		return response
+	}
+
+	userID, _ := c.Locals("user_id").(uint)
+	var agent model.User
+	if err := tenantDB(c).First(&agent, userID).Error; err != nil {
+		return response
+	}
+
+	out := fiber.Map{}
+	for k, v := range response {
+		out[k] = v
+	}
+	out["capacity"] = agentCapacity(agent)
+	return out
 }
 
 func GetChannelStats(c fiber.Ctx) error {
@@ -482,26 +887,48 @@ func GetChannelStats(c fiber.Ctx) error {
 		return c.JSON(cachedResponse)
 	}
 
-	var totalOpen, totalAssigned, totalClosed int64
+	var totalOpen, totalAssigned, totalPending, totalSnoozed, totalClosed, totalReopened int64
 
-	database.DB.Model(&model.Channel{}).Where("assigned_agent_id = ? AND status = ?", agentID, "open").Count(&totalOpen)
-	database.DB.Model(&model.Channel{}).Where("assigned_agent_id = ? AND status = ?", agentID, "assigned").Count(&totalAssigned)
-	database.DB.Model(&model.Channel{}).Where("assigned_agent_id = ? AND status = ?", agentID, "closed").Count(&totalClosed)
+	tenantDB(c).Model(&model.Channel{}).Where("assigned_agent_id = ? AND status = ?", agentID, model.ChannelOpen).Count(&totalOpen)
+	tenantDB(c).Model(&model.Channel{}).Where("assigned_agent_id = ? AND status = ?", agentID, model.ChannelAssigned).Count(&totalAssigned)
+	tenantDB(c).Model(&model.Channel{}).Where("assigned_agent_id = ? AND status = ?", agentID, model.ChannelPending).Count(&totalPending)
+	tenantDB(c).Model(&model.Channel{}).Where("assigned_agent_id = ? AND status = ?", agentID, model.ChannelSnoozed).Count(&totalSnoozed)
+	tenantDB(c).Model(&model.Channel{}).Where("assigned_agent_id = ? AND status = ?", agentID, model.ChannelClosed).Count(&totalClosed)
+	tenantDB(c).Model(&model.Channel{}).Where("assigned_agent_id = ? AND status = ?", agentID, model.ChannelReopened).Count(&totalReopened)
 
 	var totalUnread int64
-	database.DB.Model(&model.Message{}).
+	tenantDB(c).Model(&model.Message{}).
 		Joins("JOIN channels ON messages.conversation_id = channels.id").
+		Joins("LEFT JOIN read_cursors ON read_cursors.channel_id = channels.id AND read_cursors.user_id = ?", agentID).
 		Where("channels.assigned_agent_id = ? AND messages.sender_type = ?", agentID, "customer").
+		Where("messages.id > COALESCE(read_cursors.last_read_message_id, 0)").
 		Count(&totalUnread)
 
+	tagUsage := []struct {
+		Name  string `json:"name"`
+		Count int64  `json:"count"`
+	}{}
+	tenantDB(c).Table("channel_tags").
+		Select("tags.name, COUNT(*) AS count").
+		Joins("JOIN tags ON tags.id = channel_tags.tag_id").
+		Joins("JOIN channels ON channels.id = channel_tags.channel_id").
+		Where("channels.assigned_agent_id = ?", agentID).
+		Group("tags.name").
+		Order("count DESC").
+		Scan(&tagUsage)
+
 	response := fiber.Map{
 		"success": true,
 		"data": fiber.Map{
 			"open":     totalOpen,
 			"assigned": totalAssigned,
+			"pending":  totalPending,
+			"snoozed":  totalSnoozed,
 			"closed":   totalClosed,
-			"total":    totalOpen + totalAssigned + totalClosed,
+			"reopened": totalReopened,
+			"total":    totalOpen + totalAssigned + totalPending + totalSnoozed + totalClosed + totalReopened,
 			"unread":   totalUnread,
+			"tags":     tagUsage,
 		},
 	}
 
@@ -520,8 +947,9 @@ func CreateChannel(c fiber.Ctx) error {
 	}
 
 	var req struct {
-		TenantID uint   `json:"tenant_id"`
-		Message  string `json:"message"`
+		Message    string `json:"message"`
+		Skill      string `json:"skill"`
+		Department string `json:"department"`
 	}
 
 	if err := c.Bind().Body(&req); err != nil {
@@ -531,10 +959,6 @@ func CreateChannel(c fiber.Ctx) error {
 		})
 	}
 
-	if req.TenantID == 0 {
-		req.TenantID = 1
-	}
-
 	if req.Message == "" {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error":   true,
@@ -542,14 +966,29 @@ func CreateChannel(c fiber.Ctx) error {
 		})
 	}
 
-	tx := database.DB.Begin()
+	var teamID uint
+	if req.Department != "" {
+		team, err := findTeamByDepartment(tenantDB(c), req.Department)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   true,
+				"message": "Unknown department",
+			})
+		}
+		teamID = team.ID
+	}
+
+	tx := tenantDB(c).Begin()
 
 	channel := model.Channel{
-		TenantID:        req.TenantID,
+		TenantID:        currentTenantID(c),
 		CustomerID:      userID,
-		Status:          "open",
+		Status:          model.ChannelOpen,
 		AssignedAgentID: 0,
+		TeamID:          teamID,
+		Skill:           req.Skill,
 	}
+	sla.Apply(tenantDB(c), &channel, time.Now())
 
 	if err := tx.Create(&channel).Error; err != nil {
 		tx.Rollback()
@@ -563,7 +1002,6 @@ func CreateChannel(c fiber.Ctx) error {
 		ConversationID: channel.ID,
 		SenderType:     "customer",
 		Message:        req.Message,
-		IsRead:         false,
 	}
 
 	if err := tx.Create(&message).Error; err != nil {
@@ -576,19 +1014,162 @@ func CreateChannel(c fiber.Ctx) error {
 
 	tx.Commit()
 
-	utils.DeleteCache("channels:available")
+	markRead(tenantDB(c), channel, userID, message.ID)
+
+	recordEvent(tenantDB(c), channel, model.ConversationEvent{
+		Type:     model.EventCreated,
+		ActorID:  userID,
+		ToStatus: model.ChannelOpen,
+	})
+
+	invalidateAvailableChannelsCache(channel.TenantID)
 	invalidateUserConversationsCache(userID)
 
+	if agentID, err := routing.Route(database.DB, channel); err == nil {
+		channel.AssignedAgentID = agentID
+		channel.Status = model.ChannelAssigned
+		OnChannelRouted(channel, agentID)
+	}
+
 	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
 		"success": true,
 		"message": "Channel created successfully",
 		"data": fiber.Map{
-			"channel_id": channel.ID,
-			"message_id": message.ID,
+			"channel_id":            channel.ID,
+			"message_id":            message.ID,
+			"team_id":               channel.TeamID,
+			"status":                channel.Status,
+			"assigned_agent_id":     channel.AssignedAgentID,
+			"sla_policy_id":         channel.SLAPolicyID,
+			"first_response_due_at": channel.FirstResponseDueAt,
+			"resolution_due_at":     channel.ResolutionDueAt,
 		},
 	})
 }
 
+const (
+	defaultMessagePageSize = 50
+	maxMessagePageSize     = 200
+)
+
+// messagePage selects a window of a conversation. With neither cursor set it
+// returns the newest messages; Order only controls how the page is sorted.
+type messagePage struct {
+	BeforeID uint
+	AfterID  uint
+	Limit    int
+	Order    string
+}
+
+func parseMessagePage(c fiber.Ctx) (messagePage, error) {
+	page := messagePage{
+		Limit: defaultMessagePageSize,
+		Order: c.Query("order", "asc"),
+	}
+
+	if v := c.Query("before_id"); v != "" {
+		id, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			return page, errors.New("before_id must be a message ID")
+		}
+		page.BeforeID = uint(id)
+	}
+	if v := c.Query("after_id"); v != "" {
+		id, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			return page, errors.New("after_id must be a message ID")
+		}
+		page.AfterID = uint(id)
+	}
+	if page.BeforeID != 0 && page.AfterID != 0 {
+		return page, errors.New("before_id and after_id cannot be combined")
+	}
+
+	if v := c.Query("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit <= 0 {
+			return page, errors.New("limit must be a positive number")
+		}
+		page.Limit = min(limit, maxMessagePageSize)
+	}
+
+	if page.Order != "asc" && page.Order != "desc" {
+		return page, errors.New("order must be asc or desc")
+	}
+
+	return page, nil
+}
+
+// loadMessagePage fetches one page plus a cursor. before_id/after_id in the
+// cursor are the IDs to pass to fetch the older or newer neighbouring page.
+func loadMessagePage(db *gorm.DB, channelID uint, page messagePage) ([]model.Message, fiber.Map) {
+	query := db.Preload("Attachments").Where("conversation_id = ?", channelID)
+
+	forward := page.AfterID != 0
+	switch {
+	case forward:
+		query = query.Where("id > ?", page.AfterID).Order("id ASC")
+	case page.BeforeID != 0:
+		query = query.Where("id < ?", page.BeforeID).Order("id DESC")
+	default:
+		query = query.Order("id DESC")
+	}
+
+	messages := []model.Message{}
+	query.Limit(page.Limit + 1).Find(&messages)
+
+	more := len(messages) > page.Limit
+	if more {
+		messages = messages[:page.Limit]
+	}
+
+	// Normalise to oldest first, then flip if the caller wants newest first.
+	if !forward {
+		slices.Reverse(messages)
+	}
+
+	cursor := fiber.Map{
+		"before_id": nil,
+		"after_id":  nil,
+		"has_older": false,
+		"has_newer": false,
+	}
+
+	if len(messages) > 0 {
+		oldest, newest := messages[0].ID, messages[len(messages)-1].ID
+		cursor["before_id"] = oldest
+		cursor["after_id"] = newest
+
+		if forward {
+			cursor["has_newer"] = more
+			cursor["has_older"] = messageExists(db, channelID, "id < ?", oldest)
+		} else {
+			cursor["has_older"] = more
+			cursor["has_newer"] = messageExists(db, channelID, "id > ?", newest)
+		}
+	} else if page.AfterID != 0 {
+		cursor["after_id"] = page.AfterID
+	} else if page.BeforeID != 0 {
+		cursor["before_id"] = page.BeforeID
+	}
+
+	if page.Order == "desc" {
+		slices.Reverse(messages)
+	}
+
+	return messages, cursor
+}
+
+func messageExists(db *gorm.DB, channelID uint, condition string, id uint) bool {
+	var count int64
+	db.Model(&model.Message{}).
+		Where("conversation_id = ?", channelID).
+		Where(condition, id).
+		Limit(1).
+		Count(&count)
+	return count > 0
+}
+
 func getLastMessageFromCacheOrDB(channelID uint) model.Message {
 	cacheKey := fmt.Sprintf("channel:lastmessage:%d", channelID)
 
@@ -609,8 +1190,10 @@ func getLastMessageFromCacheOrDB(channelID uint) model.Message {
 	return lastMessage
 }
 
-func getUnreadCountFromCacheOrDB(channelID uint, senderType string) int64 {
-	cacheKey := fmt.Sprintf("unread:channel:%d:%s", channelID, senderType)
+// getUnreadCountFromCacheOrDB counts messages from senderType that viewerID
+// has not read yet, according to the viewer's own read cursor.
+func getUnreadCountFromCacheOrDB(channelID uint, viewerID uint, senderType string) int64 {
+	cacheKey := fmt.Sprintf("unread:channel:%d:%s:%d", channelID, senderType, viewerID)
 
 	var count int64
 	err := utils.GetCache(cacheKey, &count)
@@ -619,8 +1202,8 @@ func getUnreadCountFromCacheOrDB(channelID uint, senderType string) int64 {
 	}
 
 	database.DB.Model(&model.Message{}).
-		Where("conversation_id = ? AND sender_type = ? AND is_read = ?",
-			channelID, senderType, false).
+		Where("conversation_id = ? AND sender_type = ? AND id > ?",
+			channelID, senderType, lastReadMessageID(channelID, viewerID)).
 		Count(&count)
 
 	utils.SetCache(cacheKey, count, 5*time.Second)
@@ -628,27 +1211,26 @@ func getUnreadCountFromCacheOrDB(channelID uint, senderType string) int64 {
 	return count
 }
 
-func invalidateChannelCache(channelID uint) {
-	pattern := fmt.Sprintf("channel:%d:*", channelID)
-	keys, _ := config.RedisClient.Keys(config.Ctx, pattern).Result()
-	for _, key := range keys {
-		config.RedisClient.Del(config.Ctx, key)
+func channelIDs(channels []model.Channel) []uint {
+	ids := make([]uint, 0, len(channels))
+	for _, channel := range channels {
+		ids = append(ids, channel.ID)
 	}
+	return ids
+}
+
+func invalidateChannelCache(channelID uint) {
+	utils.DeleteCachePattern(fmt.Sprintf("channel:%d:*", channelID))
 
 	utils.DeleteCache(fmt.Sprintf("channel:lastmessage:%d", channelID))
-	utils.DeleteCache(fmt.Sprintf("unread:channel:%d:customer", channelID))
-	utils.DeleteCache(fmt.Sprintf("unread:channel:%d:agent", channelID))
+	utils.DeleteCachePattern(fmt.Sprintf("unread:channel:%d:*", channelID))
 }
 
 func invalidateAgentConversationsCache(agentID uint) {
 	if agentID == 0 {
 		return
 	}
-	pattern := fmt.Sprintf("agent:conversations:%d:*", agentID)
-	keys, _ := config.RedisClient.Keys(config.Ctx, pattern).Result()
-	for _, key := range keys {
-		config.RedisClient.Del(config.Ctx, key)
-	}
+	utils.DeleteCachePattern(fmt.Sprintf("agent:conversations:%d:*", agentID))
 
 	utils.DeleteCache(fmt.Sprintf("agent:stats:%d", agentID))
 }
@@ -657,11 +1239,7 @@ func invalidateUserConversationsCache(userID uint) {
 	if userID == 0 {
 		return
 	}
-	pattern := fmt.Sprintf("user:conversations:%d:*", userID)
-	keys, _ := config.RedisClient.Keys(config.Ctx, pattern).Result()
-	for _, key := range keys {
-		config.RedisClient.Del(config.Ctx, key)
-	}
+	utils.DeleteCachePattern(fmt.Sprintf("user:conversations:%d:*", userID))
 }
 
 func invalidateLastMessageCache(channelID uint) {
@@ -669,6 +1247,5 @@ func invalidateLastMessageCache(channelID uint) {
 }
 
 func publishMessageToRedis(channelID uint, message model.Message) {
-	messageJSON, _ := json.Marshal(message)
-	config.RedisClient.Publish(config.Ctx, fmt.Sprintf("channel:%d", channelID), messageJSON)
+	utils.PublishEvent(utils.ChannelTopic(channelID), "message", message)
 }
@@ -0,0 +1,99 @@
+package controller
+
+import (
+	"backend/model"
+	"backend/utils"
+	"strings"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+func CreateNote(c fiber.Ctx) error {
+	userID, ok := c.Locals("user_id").(uint)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   true,
+			"message": "Unauthorized",
+		})
+	}
+
+	var req struct {
+		Body string `json:"body"`
+	}
+
+	if err := c.Bind().Body(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	req.Body = strings.TrimSpace(req.Body)
+	if req.Body == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Note body is required",
+		})
+	}
+
+	channel, status, message := findStreamChannel(c)
+	if status != fiber.StatusOK {
+		return c.Status(status).JSON(fiber.Map{
+			"error":   true,
+			"message": message,
+		})
+	}
+
+	note := model.Note{
+		TenantID:  channel.TenantID,
+		ChannelID: channel.ID,
+		AuthorID:  userID,
+		Body:      req.Body,
+	}
+
+	if err := tenantDB(c).Create(&note).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to create note",
+		})
+	}
+
+	invalidateChannelCache(channel.ID)
+
+	// Notes go to the assigned agent's own topic, never to the channel topic
+	// the customer is subscribed to.
+	if channel.AssignedAgentID != 0 && channel.AssignedAgentID != userID {
+		utils.PublishEvent(utils.AgentTopic(channel.AssignedAgentID), "note", note)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"success": true,
+		"message": "Note created successfully",
+		"data":    note,
+	})
+}
+
+func GetNotes(c fiber.Ctx) error {
+	channel, status, message := findStreamChannel(c)
+	if status != fiber.StatusOK {
+		return c.Status(status).JSON(fiber.Map{
+			"error":   true,
+			"message": message,
+		})
+	}
+
+	notes := []model.Note{}
+	if err := tenantDB(c).Where("channel_id = ?", channel.ID).
+		Order("id ASC").
+		Find(&notes).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to fetch notes",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    notes,
+	})
+}
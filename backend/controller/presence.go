@@ -0,0 +1,148 @@
+package controller
+
+import (
+	"backend/utils"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// typingTTL tells clients how long to show the indicator without a refresh.
+const typingTTL = 6 * time.Second
+
+func PresenceHeartbeat(c fiber.Ctx) error {
+	userID, _ := c.Locals("user_id").(uint)
+	role, _ := c.Locals("role").(string)
+
+	var req struct {
+		State string `json:"state"`
+	}
+
+	if len(c.Body()) > 0 {
+		if err := c.Bind().Body(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   true,
+				"message": "Invalid request body",
+			})
+		}
+	}
+
+	if req.State == "" {
+		req.State = utils.PresenceOnline
+	}
+	if req.State != utils.PresenceOnline && req.State != utils.PresenceAway {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid state. Must be online or away",
+		})
+	}
+
+	presence := utils.Presence{
+		UserID:   userID,
+		TenantID: currentTenantID(c),
+		Role:     role,
+		State:    req.State,
+		LastSeen: time.Now(),
+	}
+
+	if err := utils.SetPresence(presence); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to update presence",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data": fiber.Map{
+			"presence":         presence,
+			"heartbeat_within": int(utils.PresenceTTL.Seconds()),
+		},
+	})
+}
+
+func PresenceOffline(c fiber.Ctx) error {
+	userID, _ := c.Locals("user_id").(uint)
+	role, _ := c.Locals("role").(string)
+
+	utils.SetPresence(utils.Presence{
+		UserID:   userID,
+		TenantID: currentTenantID(c),
+		Role:     role,
+		State:    utils.PresenceOffline,
+		LastSeen: time.Now(),
+	})
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Presence set to offline",
+	})
+}
+
+func GetPresenceList(c fiber.Ctx) error {
+	list, err := utils.ListPresence(currentTenantID(c))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to fetch presence",
+		})
+	}
+
+	role := c.Query("role")
+	result := []utils.Presence{}
+	for _, p := range list {
+		if role == "" || p.Role == role {
+			result = append(result, p)
+		}
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    result,
+	})
+}
+
+// SendTyping broadcasts an ephemeral typing signal on the channel topic.
+// Nothing is stored; Redis pub/sub carries it to every instance.
+func SendTyping(c fiber.Ctx) error {
+	userID, _ := c.Locals("user_id").(uint)
+	role, _ := c.Locals("role").(string)
+
+	var req struct {
+		Typing *bool `json:"typing"`
+	}
+
+	if len(c.Body()) > 0 {
+		if err := c.Bind().Body(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   true,
+				"message": "Invalid request body",
+			})
+		}
+	}
+
+	channel, status, message := findStreamChannel(c)
+	if status != fiber.StatusOK {
+		return c.Status(status).JSON(fiber.Map{
+			"error":   true,
+			"message": message,
+		})
+	}
+
+	typing := req.Typing == nil || *req.Typing
+
+	senderType := "agent"
+	if role == "user" {
+		senderType = "customer"
+	}
+
+	utils.PublishEvent(utils.ChannelTopic(channel.ID), "typing", fiber.Map{
+		"channel_id":  channel.ID,
+		"user_id":     userID,
+		"sender_type": senderType,
+		"typing":      typing,
+		"expires_in":  int(typingTTL.Seconds()),
+	})
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
@@ -0,0 +1,138 @@
+package controller
+
+import (
+	"backend/database"
+	"backend/model"
+	"backend/utils"
+	"fmt"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func lastReadMessageID(channelID uint, userID uint) uint {
+	var cursor model.ReadCursor
+	database.DB.Where("channel_id = ? AND user_id = ?", channelID, userID).Limit(1).Find(&cursor)
+	return cursor.LastReadMessageID
+}
+
+// markRead moves a participant's read cursor forward. It never moves backwards,
+// so late or duplicate requests are harmless.
+func markRead(db *gorm.DB, channel model.Channel, userID uint, messageID uint) error {
+	cursor := model.ReadCursor{
+		ChannelID:         channel.ID,
+		UserID:            userID,
+		TenantID:          channel.TenantID,
+		LastReadMessageID: messageID,
+		UpdatedAt:         time.Now(),
+	}
+
+	err := db.Clauses(clause.OnConflict{
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"last_read_message_id": gorm.Expr("GREATEST(last_read_message_id, VALUES(last_read_message_id))"),
+			"updated_at":           cursor.UpdatedAt,
+		}),
+	}).Create(&cursor).Error
+	if err != nil {
+		return err
+	}
+
+	utils.DeleteCache(fmt.Sprintf("unread:channel:%d:customer:%d", channel.ID, userID))
+	utils.DeleteCache(fmt.Sprintf("unread:channel:%d:agent:%d", channel.ID, userID))
+	return nil
+}
+
+// withReadFlags fills in Message.IsRead: a customer message counts as read
+// once any staff member's cursor has passed it, an agent message once the
+// customer's has.
+func withReadFlags(messages []model.Message, channel model.Channel, cursors []model.ReadCursor) {
+	var customerRead, staffRead uint
+	for _, cursor := range cursors {
+		if cursor.UserID == channel.CustomerID {
+			customerRead = cursor.LastReadMessageID
+		} else if cursor.LastReadMessageID > staffRead {
+			staffRead = cursor.LastReadMessageID
+		}
+	}
+
+	for i := range messages {
+		if messages[i].SenderType == "customer" {
+			messages[i].IsRead = messages[i].ID <= staffRead
+		} else {
+			messages[i].IsRead = messages[i].ID <= customerRead
+		}
+	}
+}
+
+func MarkChannelRead(c fiber.Ctx) error {
+	userID, _ := c.Locals("user_id").(uint)
+	role, _ := c.Locals("role").(string)
+
+	var req struct {
+		MessageID uint `json:"message_id"`
+	}
+
+	// An empty body marks everything up to the latest message as read.
+	if len(c.Body()) > 0 {
+		if err := c.Bind().Body(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   true,
+				"message": "Invalid request body",
+			})
+		}
+	}
+
+	channel, status, message := findStreamChannel(c)
+	if status != fiber.StatusOK {
+		return c.Status(status).JSON(fiber.Map{
+			"error":   true,
+			"message": message,
+		})
+	}
+
+	var target model.Message
+	query := tenantDB(c).Where("conversation_id = ?", channel.ID)
+	if req.MessageID != 0 {
+		query = query.Where("id = ?", req.MessageID)
+	} else {
+		query = query.Order("id DESC")
+	}
+	if err := query.First(&target).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Message not found in this channel",
+		})
+	}
+
+	if err := markRead(tenantDB(c), channel, userID, target.ID); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to mark channel as read",
+		})
+	}
+
+	lastRead := lastReadMessageID(channel.ID, userID)
+
+	if role == "user" {
+		invalidateUserConversationsCache(userID)
+	} else {
+		invalidateAgentConversationsCache(userID)
+	}
+	invalidateChannelCache(channel.ID)
+
+	receipt := fiber.Map{
+		"channel_id":           channel.ID,
+		"user_id":              userID,
+		"role":                 role,
+		"last_read_message_id": lastRead,
+	}
+	utils.PublishEvent(utils.ChannelTopic(channel.ID), "read", receipt)
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Channel marked as read",
+		"data":    receipt,
+	})
+}
@@ -0,0 +1,106 @@
+package controller
+
+import (
+	"backend/config"
+	"backend/model"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestWithReadFlags(t *testing.T) {
+	channel := model.Channel{ID: 1, CustomerID: 20, AssignedAgentID: 30}
+	messages := []model.Message{
+		{ID: 1, SenderType: "customer"},
+		{ID: 2, SenderType: "agent"},
+		{ID: 3, SenderType: "customer"},
+		{ID: 4, SenderType: "agent"},
+	}
+
+	tests := []struct {
+		name    string
+		cursors []model.ReadCursor
+		want    []bool
+	}{
+		{"nobody has read", nil, []bool{false, false, false, false}},
+		{
+			name:    "customer read up to 2",
+			cursors: []model.ReadCursor{{UserID: 20, LastReadMessageID: 2}},
+			want:    []bool{false, true, false, false},
+		},
+		{
+			name:    "agent read up to 3",
+			cursors: []model.ReadCursor{{UserID: 30, LastReadMessageID: 3}},
+			want:    []bool{true, false, true, false},
+		},
+		{
+			// Customer messages count as read once any staff member has
+			// seen them, such as an admin who looked in before the handover.
+			name: "furthest staff cursor wins",
+			cursors: []model.ReadCursor{
+				{UserID: 30, LastReadMessageID: 1},
+				{UserID: 40, LastReadMessageID: 3},
+				{UserID: 20, LastReadMessageID: 4},
+			},
+			want: []bool{true, true, true, true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page := append([]model.Message(nil), messages...)
+			withReadFlags(page, channel, tt.cursors)
+			for i, m := range page {
+				if m.IsRead != tt.want[i] {
+					t.Errorf("message %d (%s) is_read = %v, want %v", m.ID, m.SenderType, m.IsRead, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestMarkReadNeverMovesBackwards(t *testing.T) {
+	server := miniredis.RunT(t)
+	previous := config.RedisClient
+	config.RedisClient = redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() {
+		config.RedisClient.Close()
+		config.RedisClient = previous
+	})
+	server.Set("unread:channel:1:agent:30", "5")
+
+	sqlDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sqlDB.Close()
+	db, err := gorm.Open(mysql.New(mysql.Config{Conn: sqlDB, SkipInitializeWithVersion: true}),
+		&gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// One upsert per participant; an older message ID cannot lower the cursor.
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO `read_cursors` .* ON DUPLICATE KEY UPDATE " +
+		"`last_read_message_id`=GREATEST\\(last_read_message_id, VALUES\\(last_read_message_id\\)\\)").
+		WithArgs(uint(1), uint(30), uint(7), uint(12), sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	channel := model.Channel{ID: 1, TenantID: 7, CustomerID: 20, AssignedAgentID: 30}
+	if err := markRead(db, channel, 30, 12); err != nil {
+		t.Fatalf("markRead: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+	if server.Exists("unread:channel:1:agent:30") {
+		t.Error("cached unread count survived markRead")
+	}
+}
@@ -0,0 +1,346 @@
+package controller
+
+import (
+	"backend/config"
+	"backend/database"
+	"backend/model"
+	"backend/utils"
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/fasthttp/websocket"
+	"github.com/gofiber/fiber/v3"
+	"github.com/valyala/fasthttp"
+)
+
+const (
+	wsWriteWait  = 10 * time.Second
+	wsPongWait   = 60 * time.Second
+	wsPingPeriod = 50 * time.Second
+	sseHeartbeat = 25 * time.Second
+)
+
+// The socket may be authenticated by a ?token= query parameter, which the
+// browser does not guard against other sites, so the origin is checked here.
+var wsUpgrader = websocket.FastHTTPUpgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	CheckOrigin: func(ctx *fasthttp.RequestCtx) bool {
+		return config.IsAllowedOrigin(string(ctx.Request.Header.Peek("Origin")))
+	},
+}
+
+func ChannelWebSocket(c fiber.Ctx) error {
+	if !c.IsWebSocket() {
+		return c.Status(fiber.StatusUpgradeRequired).JSON(fiber.Map{
+			"error":   true,
+			"message": "WebSocket upgrade required",
+		})
+	}
+
+	channel, status, message := findStreamChannel(c)
+	if status != fiber.StatusOK {
+		return c.Status(status).JSON(fiber.Map{
+			"error":   true,
+			"message": message,
+		})
+	}
+
+	topic := utils.ChannelTopic(channel.ID)
+	userID, _ := c.Locals("user_id").(uint)
+	role, _ := c.Locals("role").(string)
+
+	sub, err := utils.Subscribe(topic)
+	if err != nil {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"error":   true,
+			"message": "Realtime updates are unavailable",
+		})
+	}
+
+	return wsUpgrader.Upgrade(c.RequestCtx(), func(conn *websocket.Conn) {
+		defer conn.Close()
+		defer sub.Close()
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			conn.SetReadDeadline(time.Now().Add(wsPongWait))
+			conn.SetPongHandler(func(string) error {
+				return conn.SetReadDeadline(time.Now().Add(wsPongWait))
+			})
+			for {
+				if _, _, err := conn.ReadMessage(); err != nil {
+					return
+				}
+			}
+		}()
+
+		ticker := time.NewTicker(wsPingPeriod)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case payload, ok := <-sub.C:
+				if !ok {
+					return
+				}
+				conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+				if err := conn.WriteMessage(websocket.TextMessage, payload); err != nil {
+					return
+				}
+
+				var event utils.Event
+				if json.Unmarshal(payload, &event) == nil && lostAccess(event, userID, role) {
+					return
+				}
+			case <-ticker.C:
+				conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+				if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+					return
+				}
+			}
+		}
+	})
+}
+
+func ChannelEvents(c fiber.Ctx) error {
+	channel, status, message := findStreamChannel(c)
+	if status != fiber.StatusOK {
+		return c.Status(status).JSON(fiber.Map{
+			"error":   true,
+			"message": message,
+		})
+	}
+
+	userID, _ := c.Locals("user_id").(uint)
+	role, _ := c.Locals("role").(string)
+
+	lastEventID := c.Get("Last-Event-ID", c.Query("last_event_id"))
+	lastID, _ := strconv.ParseUint(lastEventID, 10, 64)
+
+	c.Set("Content-Type", "text/event-stream")
+	c.Set("Cache-Control", "no-cache")
+	c.Set("Connection", "keep-alive")
+	c.Set("X-Accel-Buffering", "no")
+
+	// Subscribe before reading the backlog so nothing published in between is lost;
+	// live messages that were already part of the backlog are skipped below.
+	sub, err := utils.Subscribe(utils.ChannelTopic(channel.ID))
+	if err != nil {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"error":   true,
+			"message": "Realtime updates are unavailable",
+		})
+	}
+
+	return c.SendStreamWriter(func(w *bufio.Writer) {
+		defer sub.Close()
+
+		// Pub/sub does not deliver in ID order (each sender publishes after its
+		// own commit), so only IDs replayed here count as duplicates.
+		replayed := map[uint]struct{}{}
+		if lastID > 0 {
+			var missed []model.Message
+			database.DB.Preload("Attachments").Where("conversation_id = ? AND id > ?", channel.ID, lastID).
+				Order("id ASC").
+				Find(&missed)
+
+			for _, m := range missed {
+				data, _ := json.Marshal(m)
+				writeSSE(w, strconv.FormatUint(uint64(m.ID), 10), "message", data)
+				replayed[m.ID] = struct{}{}
+			}
+		}
+
+		fmt.Fprint(w, "retry: 3000\n\n")
+		if err := w.Flush(); err != nil {
+			return
+		}
+
+		ticker := time.NewTicker(sseHeartbeat)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case payload, ok := <-sub.C:
+				if !ok {
+					return
+				}
+
+				var event utils.Event
+				if err := json.Unmarshal(payload, &event); err != nil {
+					continue
+				}
+
+				id := ""
+				if event.Type == "message" {
+					var m model.Message
+					if err := json.Unmarshal(event.Data, &m); err != nil {
+						continue
+					}
+					if _, ok := replayed[m.ID]; ok {
+						continue
+					}
+					id = strconv.FormatUint(uint64(m.ID), 10)
+				}
+
+				writeSSE(w, id, event.Type, event.Data)
+				if lostAccess(event, userID, role) {
+					w.Flush()
+					return
+				}
+			case <-ticker.C:
+				fmt.Fprint(w, ": ping\n\n")
+			}
+
+			if err := w.Flush(); err != nil {
+				return
+			}
+		}
+	})
+}
+
+// AgentEvents streams notifications addressed to the calling agent, such as
+// conversations transferred to them.
+func AgentEvents(c fiber.Ctx) error {
+	userID, ok := c.Locals("user_id").(uint)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   true,
+			"message": "Unauthorized",
+		})
+	}
+
+	return streamTopic(c, utils.AgentTopic(userID))
+}
+
+// PresenceEvents streams presence and availability changes of the caller's
+// tenant, including heartbeats that expired.
+func PresenceEvents(c fiber.Ctx) error {
+	return streamTopic(c, utils.PresenceTopic(currentTenantID(c)))
+}
+
+// streamTopic relays every event published on topic as SSE.
+func streamTopic(c fiber.Ctx, topic string) error {
+	c.Set("Content-Type", "text/event-stream")
+	c.Set("Cache-Control", "no-cache")
+	c.Set("Connection", "keep-alive")
+	c.Set("X-Accel-Buffering", "no")
+
+	sub, err := utils.Subscribe(topic)
+	if err != nil {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"error":   true,
+			"message": "Realtime updates are unavailable",
+		})
+	}
+
+	return c.SendStreamWriter(func(w *bufio.Writer) {
+		defer sub.Close()
+
+		fmt.Fprint(w, "retry: 3000\n\n")
+		if err := w.Flush(); err != nil {
+			return
+		}
+
+		ticker := time.NewTicker(sseHeartbeat)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case payload, ok := <-sub.C:
+				if !ok {
+					return
+				}
+
+				var event utils.Event
+				if err := json.Unmarshal(payload, &event); err != nil {
+					continue
+				}
+				writeSSE(w, "", event.Type, event.Data)
+			case <-ticker.C:
+				fmt.Fprint(w, ": ping\n\n")
+			}
+
+			if err := w.Flush(); err != nil {
+				return
+			}
+		}
+	})
+}
+
+func writeSSE(w *bufio.Writer, id string, event string, data []byte) {
+	if id != "" {
+		fmt.Fprintf(w, "id: %s\n", id)
+	}
+	fmt.Fprintf(w, "event: %s\n", event)
+	fmt.Fprintf(w, "data: %s\n\n", data)
+}
+
+// lostAccess reports whether an event on a channel topic moved the channel
+// away from the agent watching it. Access is checked when a stream opens, so
+// the stream has to end here once the agent is no longer the assignee.
+func lostAccess(event utils.Event, userID uint, role string) bool {
+	if role != "agent" {
+		return false
+	}
+	switch event.Type {
+	case "assigned", "reassigned", "transfer":
+	default:
+		return false
+	}
+
+	var assignment struct {
+		AssignedAgentID uint `json:"assigned_agent_id"`
+	}
+	if err := json.Unmarshal(event.Data, &assignment); err != nil {
+		return false
+	}
+	return assignment.AssignedAgentID != userID
+}
+
+func findStreamChannel(c fiber.Ctx) (model.Channel, int, string) {
+	var channel model.Channel
+
+	userID, ok := c.Locals("user_id").(uint)
+	if !ok {
+		return channel, fiber.StatusUnauthorized, "Unauthorized"
+	}
+
+	role, _ := c.Locals("role").(string)
+	channelID := c.Params("id")
+
+	if channelID == "" {
+		return channel, fiber.StatusBadRequest, "Channel ID is required"
+	}
+
+	if err := tenantDB(c).First(&channel, channelID).Error; err != nil {
+		return channel, fiber.StatusNotFound, "Channel not found"
+	}
+
+	if allowed, message := canAccessChannel(channel, userID, role); !allowed {
+		return channel, fiber.StatusForbidden, message
+	}
+
+	return channel, fiber.StatusOK, ""
+}
+
+func canAccessChannel(channel model.Channel, userID uint, role string) (bool, string) {
+	switch role {
+	case "agent":
+		if channel.AssignedAgentID != userID {
+			return false, "Access denied. This channel is not assigned to you."
+		}
+	case "user":
+		if channel.CustomerID != userID {
+			return false, "Access denied. This is not your channel."
+		}
+	}
+	return true, ""
+}
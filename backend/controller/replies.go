@@ -0,0 +1,264 @@
+package controller
+
+import (
+	"backend/model"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/gofiber/fiber/v3"
+	"gorm.io/gorm"
+)
+
+var replyVariable = regexp.MustCompile(`\{\{\s*(\w+)\s*\}\}`)
+
+// renderReply fills {{variable}} placeholders. Unknown variables are left as
+// they are so a typo is visible instead of silently dropped.
+func renderReply(text string, vars map[string]string) string {
+	return replyVariable.ReplaceAllStringFunc(text, func(match string) string {
+		name := replyVariable.FindStringSubmatch(match)[1]
+		if value, ok := vars[name]; ok {
+			return value
+		}
+		return match
+	})
+}
+
+func replyVariables(db *gorm.DB, channel model.Channel, agentID uint) map[string]string {
+	var customer, agent model.User
+	db.Select("id", "email", "full_name").First(&customer, channel.CustomerID)
+	db.Select("id", "email", "full_name").First(&agent, agentID)
+
+	return map[string]string{
+		"customer_name":  customer.FullName,
+		"customer_email": customer.Email,
+		"agent_name":     agent.FullName,
+		"agent_email":    agent.Email,
+		"channel_id":     fmt.Sprint(channel.ID),
+	}
+}
+
+// visibleReplies scopes a query to the tenant-wide replies plus the caller's own.
+func visibleReplies(c fiber.Ctx) *gorm.DB {
+	userID, _ := c.Locals("user_id").(uint)
+	return tenantDB(c).Where("owner_id IN ?", []uint{0, userID})
+}
+
+func GetSavedReplies(c fiber.Ctx) error {
+	replies := []model.SavedReply{}
+	if err := visibleReplies(c).Order("shortcut ASC").Find(&replies).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to fetch saved replies",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    replies,
+	})
+}
+
+func SearchSavedReplies(c fiber.Ctx) error {
+	q := strings.TrimSpace(c.Query("q"))
+
+	query := visibleReplies(c)
+	if q != "" {
+		like := "%" + q + "%"
+		query = query.Where("shortcut LIKE ? OR title LIKE ? OR body LIKE ?", strings.TrimPrefix(q, "/")+"%", like, like)
+	}
+
+	replies := []model.SavedReply{}
+	if err := query.Order("shortcut ASC").Limit(20).Find(&replies).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to search saved replies",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    replies,
+	})
+}
+
+func CreateSavedReply(c fiber.Ctx) error {
+	userID, _ := c.Locals("user_id").(uint)
+	role, _ := c.Locals("role").(string)
+
+	var req struct {
+		Shortcut string `json:"shortcut"`
+		Title    string `json:"title"`
+		Body     string `json:"body"`
+		Shared   bool   `json:"shared"`
+	}
+
+	if err := c.Bind().Body(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	req.Shortcut = normalizeShortcut(req.Shortcut)
+	if req.Shortcut == "" || strings.TrimSpace(req.Body) == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Shortcut and body are required",
+		})
+	}
+
+	if req.Shared && role != "admin" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error":   true,
+			"message": "Only admins can create shared replies",
+		})
+	}
+
+	reply := model.SavedReply{
+		TenantID: currentTenantID(c),
+		OwnerID:  userID,
+		Shortcut: req.Shortcut,
+		Title:    req.Title,
+		Body:     req.Body,
+	}
+	if req.Shared {
+		reply.OwnerID = 0
+	}
+
+	if err := tenantDB(c).Create(&reply).Error; err != nil {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error":   true,
+			"message": "Shortcut already exists",
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"success": true,
+		"message": "Saved reply created successfully",
+		"data":    reply,
+	})
+}
+
+func UpdateSavedReply(c fiber.Ctx) error {
+	reply, ok := findEditableReply(c)
+	if !ok {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Saved reply not found",
+		})
+	}
+
+	var req struct {
+		Shortcut *string `json:"shortcut"`
+		Title    *string `json:"title"`
+		Body     *string `json:"body"`
+	}
+
+	if err := c.Bind().Body(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	updates := map[string]interface{}{}
+	if req.Shortcut != nil {
+		shortcut := normalizeShortcut(*req.Shortcut)
+		if shortcut == "" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   true,
+				"message": "Shortcut cannot be empty",
+			})
+		}
+		updates["shortcut"] = shortcut
+	}
+	if req.Title != nil {
+		updates["title"] = *req.Title
+	}
+	if req.Body != nil {
+		if strings.TrimSpace(*req.Body) == "" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   true,
+				"message": "Body cannot be empty",
+			})
+		}
+		updates["body"] = *req.Body
+	}
+
+	if len(updates) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "No fields to update",
+		})
+	}
+
+	if err := tenantDB(c).Model(&reply).Updates(updates).Error; err != nil {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error":   true,
+			"message": "Shortcut already exists",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Saved reply updated successfully",
+		"data":    reply,
+	})
+}
+
+func DeleteSavedReply(c fiber.Ctx) error {
+	reply, ok := findEditableReply(c)
+	if !ok {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Saved reply not found",
+		})
+	}
+
+	if err := tenantDB(c).Delete(&reply).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to delete saved reply",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Saved reply deleted successfully",
+	})
+}
+
+// findEditableReply loads a reply the caller may change: their own, or a shared
+// one when the caller is an admin.
+func findEditableReply(c fiber.Ctx) (model.SavedReply, bool) {
+	userID, _ := c.Locals("user_id").(uint)
+	role, _ := c.Locals("role").(string)
+
+	owners := []uint{userID}
+	if role == "admin" {
+		owners = append(owners, 0)
+	}
+
+	var reply model.SavedReply
+	err := tenantDB(c).Where("owner_id IN ?", owners).First(&reply, c.Params("id")).Error
+	return reply, err == nil
+}
+
+// findReplyForSend resolves a saved reply by id or shortcut for the agent's
+// message, preferring the agent's own reply over a shared one.
+func findReplyForSend(c fiber.Ctx, replyID uint, shortcut string) (model.SavedReply, bool) {
+	var reply model.SavedReply
+	query := visibleReplies(c)
+	if replyID != 0 {
+		query = query.Where("id = ?", replyID)
+	} else {
+		query = query.Where("shortcut = ?", normalizeShortcut(shortcut))
+	}
+	err := query.Order("owner_id DESC").First(&reply).Error
+	return reply, err == nil
+}
+
+func normalizeShortcut(shortcut string) string {
+	return strings.ToLower(strings.TrimPrefix(strings.TrimSpace(shortcut), "/"))
+}
@@ -0,0 +1,100 @@
+package controller
+
+import (
+	"backend/database"
+	"backend/model"
+	"backend/routing"
+	"backend/utils"
+
+	"github.com/gofiber/fiber/v3"
+	"gorm.io/gorm/clause"
+)
+
+// OnChannelRouted refreshes caches and tells the conversation an agent joined
+// after the routing engine assigned it.
+func OnChannelRouted(channel model.Channel, agentID uint) {
+	invalidateChannelCache(channel.ID)
+	invalidateAgentConversationsCache(agentID)
+	invalidateUserConversationsCache(channel.CustomerID)
+	invalidateAvailableChannelsCache(channel.TenantID)
+
+	recordEvent(database.DB, channel, model.ConversationEvent{
+		Type:      model.EventRouted,
+		ToStatus:  model.ChannelAssigned,
+		ToAgentID: agentID,
+	})
+
+	utils.PublishEvent(utils.ChannelTopic(channel.ID), "assigned", fiber.Map{
+		"channel_id":        channel.ID,
+		"assigned_agent_id": agentID,
+		"status":            model.ChannelAssigned,
+	})
+}
+
+func GetRoutingSettings(c fiber.Ctx) error {
+	settings := routing.Settings(database.DB, currentTenantID(c))
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data": fiber.Map{
+			"settings":   settings,
+			"strategies": routing.StrategyNames(),
+		},
+	})
+}
+
+func UpdateRoutingSettings(c fiber.Ctx) error {
+	var req struct {
+		Strategy         *string `json:"strategy"`
+		AutoAssign       *bool   `json:"auto_assign"`
+		MaxChatsPerAgent *int    `json:"max_chats_per_agent"`
+	}
+
+	if err := c.Bind().Body(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	settings := routing.Settings(database.DB, currentTenantID(c))
+
+	if req.Strategy != nil {
+		if _, ok := routing.Lookup(*req.Strategy); !ok {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   true,
+				"message": "Unknown routing strategy",
+				"data":    routing.StrategyNames(),
+			})
+		}
+		settings.Strategy = *req.Strategy
+	}
+	if req.AutoAssign != nil {
+		settings.AutoAssign = *req.AutoAssign
+	}
+	if req.MaxChatsPerAgent != nil {
+		if *req.MaxChatsPerAgent < 0 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   true,
+				"message": "max_chats_per_agent cannot be negative",
+			})
+		}
+		settings.MaxChatsPerAgent = *req.MaxChatsPerAgent
+	}
+
+	err := database.DB.Clauses(clause.OnConflict{
+		DoUpdates: clause.AssignmentColumns([]string{"strategy", "auto_assign", "max_chats_per_agent", "updated_at"}),
+	}).Create(&settings).Error
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to update routing settings",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Routing settings updated successfully",
+		"data":    settings,
+	})
+}
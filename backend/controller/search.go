@@ -0,0 +1,117 @@
+package controller
+
+import (
+	"backend/model"
+	"backend/search"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+func SearchConversations(c fiber.Ctx) error {
+	userID, _ := c.Locals("user_id").(uint)
+	role, _ := c.Locals("role").(string)
+	superAdmin, _ := c.Locals("super_admin").(bool)
+
+	text := strings.TrimSpace(c.Query("q"))
+	if len(search.Terms(text)) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Search query is required",
+		})
+	}
+
+	limit, _ := strconv.Atoi(c.Query("limit", "20"))
+	offset, _ := strconv.Atoi(c.Query("offset", "0"))
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	q := search.Query{
+		Text:       text,
+		TenantID:   currentTenantID(c),
+		AllTenants: superAdmin,
+		Tags:       parseTagFilter(c.Query("tag")),
+		Limit:      limit,
+		Offset:     offset,
+	}
+
+	// Agents only search their own conversations; scope=queue adds the
+	// unassigned queues they could claim anyway.
+	if role == "agent" {
+		q.AgentID = userID
+		q.IncludeQueue = c.Query("scope") == "queue"
+		if q.IncludeQueue {
+			q.QueueTeamIDs = agentTeamIDs(userID)
+		}
+	}
+
+	for _, status := range strings.Split(c.Query("status"), ",") {
+		if status = strings.TrimSpace(status); status == "" {
+			continue
+		}
+		if !model.IsValidChannelStatus(status) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   true,
+				"message": "Invalid status filter",
+			})
+		}
+		q.Statuses = append(q.Statuses, status)
+	}
+
+	var err error
+	if q.From, err = parseSearchDate(c.Query("from"), false); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "from must be YYYY-MM-DD or RFC3339",
+		})
+	}
+	if q.To, err = parseSearchDate(c.Query("to"), true); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "to must be YYYY-MM-DD or RFC3339",
+		})
+	}
+
+	result, err := search.Default().Search(c.Context(), q)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Search failed",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    result.Hits,
+		"pagination": fiber.Map{
+			"total":  result.Total,
+			"limit":  limit,
+			"offset": offset,
+		},
+	})
+}
+
+// parseSearchDate accepts a date or a timestamp. A bare date used as the end
+// of a range covers that whole day.
+func parseSearchDate(value string, endOfRange bool) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return &t, nil
+	}
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return nil, err
+	}
+	if endOfRange {
+		t = t.AddDate(0, 0, 1)
+	}
+	return &t, nil
+}
@@ -0,0 +1,225 @@
+package controller
+
+import (
+	"backend/database"
+	"backend/model"
+	"backend/utils"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+func createSession(c fiber.Ctx, userID uint, device string) (model.Session, error) {
+	if device == "" {
+		device = "Unknown device"
+	}
+
+	now := time.Now()
+	session := model.Session{
+		ID:         utils.GenerateSessionID(),
+		UserID:     userID,
+		Device:     device,
+		IPAddress:  c.IP(),
+		UserAgent:  c.Get("User-Agent"),
+		LastUsedAt: now,
+		ExpiresAt:  now.Add(refreshTokenTTL),
+	}
+
+	err := database.DB.Create(&session).Error
+	return session, err
+}
+
+func revokeSession(session model.Session) error {
+	now := time.Now()
+	if err := database.DB.Model(&session).Update("revoked_at", now).Error; err != nil {
+		return err
+	}
+	return utils.Revocations.Revoke("session:"+session.ID, now.Add(utils.MaxAccessTokenTTL))
+}
+
+// revokeAllSessions revokes every session of the user by ID as well as by
+// cut-off time. The cut-off only has one-second resolution, so an access token
+// minted in the same second is caught by its session instead.
+func revokeAllSessions(userID uint) error {
+	now := time.Now()
+
+	var sessionIDs []string
+	if err := database.DB.Model(&model.Session{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Pluck("id", &sessionIDs).Error; err != nil {
+		return err
+	}
+
+	if len(sessionIDs) > 0 {
+		if err := database.DB.Model(&model.Session{}).
+			Where("id IN ?", sessionIDs).
+			Update("revoked_at", now).Error; err != nil {
+			return err
+		}
+	}
+	for _, sessionID := range sessionIDs {
+		if err := utils.Revocations.Revoke("session:"+sessionID, now.Add(utils.MaxAccessTokenTTL)); err != nil {
+			return err
+		}
+	}
+
+	utils.RevokeAllRefreshTokens(userID)
+	return utils.Revocations.RevokeUser(userID, now.Add(utils.MaxAccessTokenTTL))
+}
+
+func listActiveSessions(userID uint) ([]model.Session, error) {
+	var sessions []model.Session
+	err := database.DB.Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Order("last_used_at DESC").
+		Find(&sessions).Error
+	return sessions, err
+}
+
+func GetMySessions(c fiber.Ctx) error {
+	userID, ok := c.Locals("user_id").(uint)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   true,
+			"message": "Unauthorized",
+		})
+	}
+
+	sessions, err := listActiveSessions(userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to fetch sessions",
+		})
+	}
+
+	currentID, _ := c.Locals("session_id").(string)
+
+	var responseData []fiber.Map
+	for _, session := range sessions {
+		responseData = append(responseData, fiber.Map{
+			"id":           session.ID,
+			"device":       session.Device,
+			"ip_address":   session.IPAddress,
+			"user_agent":   session.UserAgent,
+			"created_at":   session.CreatedAt,
+			"last_used_at": session.LastUsedAt,
+			"expires_at":   session.ExpiresAt,
+			"current":      session.ID == currentID,
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    responseData,
+		"total":   len(responseData),
+	})
+}
+
+func RevokeMySession(c fiber.Ctx) error {
+	userID, ok := c.Locals("user_id").(uint)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   true,
+			"message": "Unauthorized",
+		})
+	}
+
+	return revokeSessionOf(c, userID, c.Params("id"))
+}
+
+func RevokeMySessions(c fiber.Ctx) error {
+	userID, ok := c.Locals("user_id").(uint)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   true,
+			"message": "Unauthorized",
+		})
+	}
+
+	return revokeSessionsOf(c, userID)
+}
+
+func GetUserSessions(c fiber.Ctx) error {
+	user, err := findUserByParam(c, false)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "User not found",
+		})
+	}
+
+	sessions, err := listActiveSessions(user.ID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to fetch sessions",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    sessions,
+		"total":   len(sessions),
+	})
+}
+
+func RevokeUserSession(c fiber.Ctx) error {
+	user, err := findUserByParam(c, false)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "User not found",
+		})
+	}
+
+	return revokeSessionOf(c, user.ID, c.Params("sessionId"))
+}
+
+func RevokeUserSessions(c fiber.Ctx) error {
+	user, err := findUserByParam(c, false)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "User not found",
+		})
+	}
+
+	return revokeSessionsOf(c, user.ID)
+}
+
+func revokeSessionOf(c fiber.Ctx, userID uint, sessionID string) error {
+	var session model.Session
+	if err := database.DB.Where("id = ? AND user_id = ? AND revoked_at IS NULL", sessionID, userID).
+		First(&session).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Session not found",
+		})
+	}
+
+	if err := revokeSession(session); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to revoke session",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Session revoked successfully",
+	})
+}
+
+func revokeSessionsOf(c fiber.Ctx, userID uint) error {
+	if err := revokeAllSessions(userID); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to revoke sessions",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "All sessions revoked successfully",
+	})
+}
@@ -0,0 +1,337 @@
+package controller
+
+import (
+	"backend/database"
+	"backend/model"
+	"backend/sla"
+	"backend/utils"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+	"gorm.io/gorm"
+)
+
+type slaPolicyRequest struct {
+	Name                 *string `json:"name"`
+	TeamID               *uint   `json:"team_id"`
+	FirstResponseMinutes *int    `json:"first_response_minutes"`
+	ResolutionMinutes    *int    `json:"resolution_minutes"`
+	BusinessHoursOnly    *bool   `json:"business_hours_only"`
+	Timezone             *string `json:"timezone"`
+	BusinessStart        *string `json:"business_start"`
+	BusinessEnd          *string `json:"business_end"`
+	BusinessDays         *string `json:"business_days"`
+	IsActive             *bool   `json:"is_active"`
+}
+
+// apply copies the fields that were sent onto policy.
+func (req slaPolicyRequest) apply(policy *model.SLAPolicy) {
+	if req.Name != nil {
+		policy.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.TeamID != nil {
+		policy.TeamID = *req.TeamID
+	}
+	if req.FirstResponseMinutes != nil {
+		policy.FirstResponseMinutes = *req.FirstResponseMinutes
+	}
+	if req.ResolutionMinutes != nil {
+		policy.ResolutionMinutes = *req.ResolutionMinutes
+	}
+	if req.BusinessHoursOnly != nil {
+		policy.BusinessHoursOnly = *req.BusinessHoursOnly
+	}
+	if req.Timezone != nil {
+		policy.Timezone = strings.TrimSpace(*req.Timezone)
+	}
+	if req.BusinessStart != nil {
+		policy.BusinessStart = strings.TrimSpace(*req.BusinessStart)
+	}
+	if req.BusinessEnd != nil {
+		policy.BusinessEnd = strings.TrimSpace(*req.BusinessEnd)
+	}
+	if req.BusinessDays != nil {
+		policy.BusinessDays = strings.TrimSpace(*req.BusinessDays)
+	}
+	if req.IsActive != nil {
+		policy.IsActive = *req.IsActive
+	}
+}
+
+func validateSLAPolicy(c fiber.Ctx, policy model.SLAPolicy) string {
+	if policy.Name == "" {
+		return "Policy name is required"
+	}
+	if policy.FirstResponseMinutes <= 0 || policy.ResolutionMinutes <= 0 {
+		return "first_response_minutes and resolution_minutes must be positive"
+	}
+	if policy.ResolutionMinutes < policy.FirstResponseMinutes {
+		return "resolution_minutes cannot be shorter than first_response_minutes"
+	}
+	if _, err := time.LoadLocation(policy.Timezone); err != nil || policy.Timezone == "" {
+		return "Unknown timezone"
+	}
+	if !sla.ValidClock(policy.BusinessStart) || !sla.ValidClock(policy.BusinessEnd) || policy.BusinessStart >= policy.BusinessEnd {
+		return "business_start and business_end must be HH:MM with start before end"
+	}
+	if policy.BusinessHoursOnly && len(sla.ScheduleFor(policy).Days) == 0 {
+		return "business_days must list at least one day (0 = Sunday ... 6 = Saturday)"
+	}
+	if policy.TeamID != 0 {
+		var team model.Team
+		if err := tenantDB(c).First(&team, policy.TeamID).Error; err != nil {
+			return "Team not found"
+		}
+	}
+	return ""
+}
+
+func GetSLAPolicies(c fiber.Ctx) error {
+	policies := []model.SLAPolicy{}
+	if err := tenantDB(c).Order("team_id ASC, name ASC").Find(&policies).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to fetch SLA policies",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    policies,
+	})
+}
+
+func CreateSLAPolicy(c fiber.Ctx) error {
+	var req slaPolicyRequest
+	if err := c.Bind().Body(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	policy := model.SLAPolicy{
+		TenantID:      currentTenantID(c),
+		Timezone:      "UTC",
+		BusinessStart: "09:00",
+		BusinessEnd:   "17:00",
+		BusinessDays:  "1,2,3,4,5",
+		IsActive:      true,
+	}
+	req.apply(&policy)
+
+	if message := validateSLAPolicy(c, policy); message != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": message,
+		})
+	}
+
+	if err := tenantDB(c).Create(&policy).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to create SLA policy",
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"success": true,
+		"message": "SLA policy created successfully",
+		"data":    policy,
+	})
+}
+
+// UpdateSLAPolicy only affects conversations created afterwards; deadlines
+// already set on channels are kept.
+func UpdateSLAPolicy(c fiber.Ctx) error {
+	var policy model.SLAPolicy
+	if err := tenantDB(c).First(&policy, c.Params("id")).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "SLA policy not found",
+		})
+	}
+
+	var req slaPolicyRequest
+	if err := c.Bind().Body(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+	req.apply(&policy)
+
+	if message := validateSLAPolicy(c, policy); message != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": message,
+		})
+	}
+
+	if err := tenantDB(c).Save(&policy).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to update SLA policy",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "SLA policy updated successfully",
+		"data":    policy,
+	})
+}
+
+func DeleteSLAPolicy(c fiber.Ctx) error {
+	result := tenantDB(c).Delete(&model.SLAPolicy{}, c.Params("id"))
+	if result.Error != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to delete SLA policy",
+		})
+	}
+	if result.RowsAffected == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "SLA policy not found",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "SLA policy deleted successfully",
+	})
+}
+
+// recordFirstResponse stops the first-response clock on the first agent reply.
+func recordFirstResponse(db *gorm.DB, channel *model.Channel, at time.Time) {
+	if channel.FirstRespondedAt != nil {
+		return
+	}
+
+	result := db.Model(&model.Channel{}).
+		Where("id = ? AND first_responded_at IS NULL", channel.ID).
+		Update("first_responded_at", at)
+	if result.Error == nil && result.RowsAffected > 0 {
+		channel.FirstRespondedAt = &at
+	}
+}
+
+// restartResolutionClock gives a reopened conversation a fresh resolution
+// deadline under the policy it was created with.
+func restartResolutionClock(db *gorm.DB, channel *model.Channel, at time.Time) {
+	if channel.SLAPolicyID == 0 {
+		return
+	}
+
+	var policy model.SLAPolicy
+	if err := db.First(&policy, channel.SLAPolicyID).Error; err != nil {
+		return
+	}
+
+	due := sla.ScheduleFor(policy).Add(at, time.Duration(policy.ResolutionMinutes)*time.Minute)
+	db.Model(&model.Channel{}).Where("id = ?", channel.ID).Updates(map[string]interface{}{
+		"resolution_due_at":      due,
+		"resolution_breached_at": nil,
+	})
+	channel.ResolutionDueAt = &due
+	channel.ResolutionBreachedAt = nil
+}
+
+// withSLAFilter applies the sla= filter of the conversation listings.
+func withSLAFilter(query *gorm.DB, filter string) *gorm.DB {
+	switch filter {
+	case "breached":
+		return sla.Breached(query, time.Now())
+	case "breaching_soon":
+		return sla.BreachingSoon(query, time.Now(), sla.BreachingSoonWindow)
+	}
+	return query
+}
+
+// StartSLAChecker marks missed deadlines once, records them on the timeline
+// and notifies the agents responsible for the conversation.
+func StartSLAChecker(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	go func() {
+		for range ticker.C {
+			now := time.Now()
+			checkSLABreaches(now, model.SLAFirstResponse,
+				"first_responded_at IS NULL AND first_response_breached_at IS NULL AND first_response_due_at < ?",
+				"first_response_breached_at")
+			checkSLABreaches(now, model.SLAResolution,
+				"resolution_breached_at IS NULL AND resolution_due_at < ?",
+				"resolution_breached_at")
+		}
+	}()
+}
+
+func checkSLABreaches(now time.Time, target string, condition string, column string) {
+	var channels []model.Channel
+	err := database.DB.Where(condition, now).
+		Where("status NOT IN ?", sla.StoppedStatuses).
+		Limit(500).
+		Find(&channels).Error
+	if err != nil {
+		log.Printf("sla checker: %v", err)
+		return
+	}
+
+	for _, channel := range channels {
+		result := database.DB.Model(&model.Channel{}).
+			Where("id = ? AND "+column+" IS NULL", channel.ID).
+			Update(column, now)
+		if result.Error != nil || result.RowsAffected == 0 {
+			continue
+		}
+
+		recordEvent(database.DB, channel, model.ConversationEvent{
+			Type:        model.EventSLABreached,
+			FromStatus:  channel.Status,
+			ToStatus:    channel.Status,
+			FromAgentID: channel.AssignedAgentID,
+			Note:        target,
+		})
+
+		due := channel.ResolutionDueAt
+		if target == model.SLAFirstResponse {
+			due = channel.FirstResponseDueAt
+		}
+
+		payload := fiber.Map{
+			"channel_id":        channel.ID,
+			"tenant_id":         channel.TenantID,
+			"team_id":           channel.TeamID,
+			"assigned_agent_id": channel.AssignedAgentID,
+			"target":            target,
+			"due_at":            due,
+			"breached_at":       now,
+		}
+		for _, agentID := range slaBreachRecipients(channel) {
+			utils.PublishEvent(utils.AgentTopic(agentID), "sla_breach", payload)
+			invalidateAgentConversationsCache(agentID)
+		}
+	}
+}
+
+// slaBreachRecipients is the assigned agent or, for a queued conversation,
+// the agents who can claim it.
+func slaBreachRecipients(channel model.Channel) []uint {
+	if channel.AssignedAgentID != 0 {
+		return []uint{channel.AssignedAgentID}
+	}
+
+	var ids []uint
+	query := database.DB.Model(&model.User{}).
+		Where("tenant_id = ? AND role = ? AND is_active = ? AND availability <> ?",
+			channel.TenantID, model.RoleAgent, true, model.AvailabilityOffline)
+	if channel.TeamID != 0 {
+		query = query.Where("id IN (?)", database.DB.Model(&model.TeamMember{}).
+			Select("user_id").
+			Where("team_id = ?", channel.TeamID))
+	}
+	query.Pluck("id", &ids)
+	return ids
+}
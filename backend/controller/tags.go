@@ -0,0 +1,336 @@
+package controller
+
+import (
+	"backend/database"
+	"backend/model"
+	"strings"
+
+	"github.com/gofiber/fiber/v3"
+	"gorm.io/gorm"
+)
+
+func GetTags(c fiber.Ctx) error {
+	tags := []model.Tag{}
+	if err := tenantDB(c).Order("name ASC").Find(&tags).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to fetch tags",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    tags,
+	})
+}
+
+func CreateTag(c fiber.Ctx) error {
+	var req struct {
+		Name  string `json:"name"`
+		Color string `json:"color"`
+	}
+
+	if err := c.Bind().Body(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	req.Name = normalizeTagName(req.Name)
+	if req.Name == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Tag name is required",
+		})
+	}
+
+	tag := model.Tag{
+		TenantID: currentTenantID(c),
+		Name:     req.Name,
+		Color:    req.Color,
+	}
+
+	if err := tenantDB(c).Create(&tag).Error; err != nil {
+		if database.IsDuplicateKey(err) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error":   true,
+				"message": "Tag already exists",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to create tag",
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"success": true,
+		"message": "Tag created successfully",
+		"data":    tag,
+	})
+}
+
+func UpdateTag(c fiber.Ctx) error {
+	var tag model.Tag
+	if err := tenantDB(c).First(&tag, c.Params("id")).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Tag not found",
+		})
+	}
+
+	var req struct {
+		Name  *string `json:"name"`
+		Color *string `json:"color"`
+	}
+
+	if err := c.Bind().Body(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	updates := map[string]interface{}{}
+	if req.Name != nil {
+		name := normalizeTagName(*req.Name)
+		if name == "" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   true,
+				"message": "Tag name cannot be empty",
+			})
+		}
+		updates["name"] = name
+	}
+	if req.Color != nil {
+		updates["color"] = *req.Color
+	}
+
+	if len(updates) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "No fields to update",
+		})
+	}
+
+	if err := tenantDB(c).Model(&tag).Updates(updates).Error; err != nil {
+		if database.IsDuplicateKey(err) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error":   true,
+				"message": "Tag already exists",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to update tag",
+		})
+	}
+	if req.Name != nil {
+		invalidateTagCaches(tag.TenantID)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Tag updated successfully",
+		"data":    tag,
+	})
+}
+
+func DeleteTag(c fiber.Ctx) error {
+	var tag model.Tag
+	if err := tenantDB(c).First(&tag, c.Params("id")).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Tag not found",
+		})
+	}
+
+	err := tenantDB(c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM channel_tags WHERE tag_id = ?", tag.ID).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&tag).Error
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to delete tag",
+		})
+	}
+	invalidateTagCaches(tag.TenantID)
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Tag deleted successfully",
+	})
+}
+
+// invalidateTagCaches drops the listings that may be filtered by a tag which
+// was renamed or deleted: the tenant's queue and its agents' conversations.
+func invalidateTagCaches(tenantID uint) {
+	invalidateAvailableChannelsCache(tenantID)
+
+	var agentIDs []uint
+	database.DB.Model(&model.User{}).
+		Where("tenant_id = ? AND role = ?", tenantID, model.RoleAgent).
+		Pluck("id", &agentIDs)
+	for _, agentID := range agentIDs {
+		invalidateAgentConversationsCache(agentID)
+	}
+}
+
+func AddChannelTag(c fiber.Ctx) error {
+	userID, _ := c.Locals("user_id").(uint)
+
+	var req struct {
+		TagID uint `json:"tag_id"`
+	}
+
+	if err := c.Bind().Body(&req); err != nil || req.TagID == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "tag_id is required",
+		})
+	}
+
+	channel, status, message := findStreamChannel(c)
+	if status != fiber.StatusOK {
+		return c.Status(status).JSON(fiber.Map{
+			"error":   true,
+			"message": message,
+		})
+	}
+
+	var tag model.Tag
+	if err := tenantDB(c).First(&tag, req.TagID).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Tag not found",
+		})
+	}
+
+	if err := tenantDB(c).Model(&channel).Association("Tags").Append(&tag); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to tag channel",
+		})
+	}
+
+	recordEvent(tenantDB(c), channel, model.ConversationEvent{
+		Type:    model.EventTagged,
+		ActorID: userID,
+		Note:    tag.Name,
+	})
+	refreshChannelTags(channel)
+
+	return channelTagsResponse(c, channel, "Tag added successfully")
+}
+
+func RemoveChannelTag(c fiber.Ctx) error {
+	userID, _ := c.Locals("user_id").(uint)
+
+	channel, status, message := findStreamChannel(c)
+	if status != fiber.StatusOK {
+		return c.Status(status).JSON(fiber.Map{
+			"error":   true,
+			"message": message,
+		})
+	}
+
+	var tag model.Tag
+	if err := tenantDB(c).First(&tag, c.Params("tagId")).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Tag not found",
+		})
+	}
+
+	if err := tenantDB(c).Model(&channel).Association("Tags").Delete(&tag); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to untag channel",
+		})
+	}
+
+	recordEvent(tenantDB(c), channel, model.ConversationEvent{
+		Type:    model.EventUntagged,
+		ActorID: userID,
+		Note:    tag.Name,
+	})
+	refreshChannelTags(channel)
+
+	return channelTagsResponse(c, channel, "Tag removed successfully")
+}
+
+func channelTagsResponse(c fiber.Ctx, channel model.Channel, message string) error {
+	tags := []model.Tag{}
+	tenantDB(c).Model(&channel).Association("Tags").Find(&tags)
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": message,
+		"data": fiber.Map{
+			"channel_id": channel.ID,
+			"tags":       tags,
+		},
+	})
+}
+
+func refreshChannelTags(channel model.Channel) {
+	invalidateChannelCache(channel.ID)
+	invalidateAgentConversationsCache(channel.AssignedAgentID)
+	if channel.AssignedAgentID == 0 {
+		invalidateAvailableChannelsCache(channel.TenantID)
+	}
+}
+
+func normalizeTagName(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// parseTagFilter turns a "tag=billing,vip" query value into tag names.
+func parseTagFilter(raw string) []string {
+	var names []string
+	for _, name := range strings.Split(raw, ",") {
+		if name = normalizeTagName(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// withTags limits a channel query to channels carrying any of the given tags.
+func withTags(query *gorm.DB, names []string) *gorm.DB {
+	if len(names) == 0 {
+		return query
+	}
+	return query.Where("channels.id IN (?)", database.DB.Table("channel_tags").
+		Select("channel_tags.channel_id").
+		Joins("JOIN tags ON tags.id = channel_tags.tag_id").
+		Where("tags.name IN ?", names))
+}
+
+// channelTagNames loads the tag names of each channel in one query.
+func channelTagNames(channelIDs []uint) map[uint][]string {
+	names := map[uint][]string{}
+	if len(channelIDs) == 0 {
+		return names
+	}
+
+	var rows []struct {
+		ChannelID uint
+		Name      string
+	}
+	database.DB.Table("channel_tags").
+		Select("channel_tags.channel_id, tags.name").
+		Joins("JOIN tags ON tags.id = channel_tags.tag_id").
+		Where("channel_tags.channel_id IN ?", channelIDs).
+		Order("tags.name ASC").
+		Scan(&rows)
+
+	for _, row := range rows {
+		names[row.ChannelID] = append(names[row.ChannelID], row.Name)
+	}
+	return names
+}
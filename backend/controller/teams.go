@@ -0,0 +1,376 @@
+package controller
+
+import (
+	"backend/database"
+	"backend/model"
+	"strings"
+
+	"github.com/gofiber/fiber/v3"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func agentTeamIDs(agentID uint) []uint {
+	var ids []uint
+	database.DB.Model(&model.TeamMember{}).Where("user_id = ?", agentID).Pluck("team_id", &ids)
+	return ids
+}
+
+func teamMemberIDs(teamID uint) []uint {
+	var ids []uint
+	database.DB.Model(&model.TeamMember{}).Where("team_id = ?", teamID).Pluck("user_id", &ids)
+	return ids
+}
+
+// findTeamByDepartment resolves the department a customer picked, by slug or
+// by name.
+func findTeamByDepartment(db *gorm.DB, department string) (model.Team, error) {
+	var team model.Team
+	err := db.Where("slug = ? OR name = ?", slugify(department), strings.TrimSpace(department)).
+		First(&team).Error
+	return team, err
+}
+
+func slugify(s string) string {
+	s = strings.ToLower(strings.TrimSpace(s))
+	return strings.Join(strings.FieldsFunc(s, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9')
+	}), "-")
+}
+
+func GetTeams(c fiber.Ctx) error {
+	teams := []model.Team{}
+	if err := tenantDB(c).Order("name ASC").Find(&teams).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to fetch teams",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    teams,
+	})
+}
+
+func GetMyTeams(c fiber.Ctx) error {
+	userID, _ := c.Locals("user_id").(uint)
+
+	teams := []model.Team{}
+	if ids := agentTeamIDs(userID); len(ids) > 0 {
+		tenantDB(c).Where("id IN ?", ids).Order("name ASC").Find(&teams)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    teams,
+	})
+}
+
+func CreateTeam(c fiber.Ctx) error {
+	var req struct {
+		Name        string `json:"name"`
+		Slug        string `json:"slug"`
+		Description string `json:"description"`
+	}
+
+	if err := c.Bind().Body(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Slug == "" {
+		req.Slug = req.Name
+	}
+	req.Slug = slugify(req.Slug)
+
+	if req.Name == "" || req.Slug == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Team name is required",
+		})
+	}
+
+	team := model.Team{
+		TenantID:    currentTenantID(c),
+		Name:        req.Name,
+		Slug:        req.Slug,
+		Description: req.Description,
+	}
+
+	if err := tenantDB(c).Create(&team).Error; err != nil {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error":   true,
+			"message": "Team slug already exists",
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"success": true,
+		"message": "Team created successfully",
+		"data":    team,
+	})
+}
+
+func UpdateTeam(c fiber.Ctx) error {
+	var team model.Team
+	if err := tenantDB(c).First(&team, c.Params("id")).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Team not found",
+		})
+	}
+
+	var req struct {
+		Name        *string `json:"name"`
+		Slug        *string `json:"slug"`
+		Description *string `json:"description"`
+	}
+
+	if err := c.Bind().Body(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	updates := map[string]interface{}{}
+	if req.Name != nil && strings.TrimSpace(*req.Name) != "" {
+		updates["name"] = strings.TrimSpace(*req.Name)
+	}
+	if req.Slug != nil && slugify(*req.Slug) != "" {
+		updates["slug"] = slugify(*req.Slug)
+	}
+	if req.Description != nil {
+		updates["description"] = *req.Description
+	}
+
+	if len(updates) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "No fields to update",
+		})
+	}
+
+	if err := tenantDB(c).Model(&team).Updates(updates).Error; err != nil {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error":   true,
+			"message": "Team slug already exists",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Team updated successfully",
+		"data":    team,
+	})
+}
+
+// DeleteTeam moves the team's channels back to the tenant-wide queue so none
+// of them become unreachable.
+func DeleteTeam(c fiber.Ctx) error {
+	var team model.Team
+	if err := tenantDB(c).First(&team, c.Params("id")).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Team not found",
+		})
+	}
+
+	err := tenantDB(c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.Channel{}).Where("team_id = ?", team.ID).Update("team_id", 0).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("team_id = ?", team.ID).Delete(&model.TeamMember{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&team).Error
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to delete team",
+		})
+	}
+
+	invalidateAvailableChannelsCache(team.TenantID)
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Team deleted successfully",
+	})
+}
+
+func GetTeamMembers(c fiber.Ctx) error {
+	var team model.Team
+	if err := tenantDB(c).First(&team, c.Params("id")).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Team not found",
+		})
+	}
+
+	members := []model.User{}
+	tenantDB(c).Select("id", "email", "full_name", "availability").
+		Where("id IN (?)", database.DB.Model(&model.TeamMember{}).Select("user_id").Where("team_id = ?", team.ID)).
+		Order("id ASC").
+		Find(&members)
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    members,
+	})
+}
+
+func AddTeamMember(c fiber.Ctx) error {
+	var req struct {
+		UserID uint `json:"user_id"`
+	}
+
+	if err := c.Bind().Body(&req); err != nil || req.UserID == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "user_id is required",
+		})
+	}
+
+	var team model.Team
+	if err := tenantDB(c).First(&team, c.Params("id")).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Team not found",
+		})
+	}
+
+	var agent model.User
+	if err := tenantDB(c).Where("role = ?", model.RoleAgent).First(&agent, req.UserID).Error; err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Agent not found",
+		})
+	}
+
+	member := model.TeamMember{TeamID: team.ID, UserID: agent.ID}
+	if err := database.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&member).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to add team member",
+		})
+	}
+
+	invalidateAvailableChannelsCache(team.TenantID)
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"success": true,
+		"message": "Team member added successfully",
+		"data":    member,
+	})
+}
+
+func RemoveTeamMember(c fiber.Ctx) error {
+	var team model.Team
+	if err := tenantDB(c).First(&team, c.Params("id")).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Team not found",
+		})
+	}
+
+	result := database.DB.Where("team_id = ? AND user_id = ?", team.ID, c.Params("userId")).
+		Delete(&model.TeamMember{})
+	if result.Error != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to remove team member",
+		})
+	}
+	if result.RowsAffected == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Agent is not a member of this team",
+		})
+	}
+
+	invalidateAvailableChannelsCache(team.TenantID)
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Team member removed successfully",
+	})
+}
+
+func GetTeamStats(c fiber.Ctx) error {
+	var teams []model.Team
+	if err := tenantDB(c).Order("name ASC").Find(&teams).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to fetch teams",
+		})
+	}
+
+	var counts []struct {
+		TeamID uint
+		Status string
+		Count  int64
+	}
+	tenantDB(c).Model(&model.Channel{}).
+		Select("team_id, status, COUNT(*) AS count").
+		Group("team_id, status").
+		Scan(&counts)
+
+	byTeam := map[uint]map[string]int64{}
+	for _, row := range counts {
+		if byTeam[row.TeamID] == nil {
+			byTeam[row.TeamID] = map[string]int64{}
+		}
+		byTeam[row.TeamID][row.Status] = row.Count
+	}
+
+	var queued []struct {
+		TeamID uint
+		Count  int64
+	}
+	tenantDB(c).Model(&model.Channel{}).
+		Select("team_id, COUNT(*) AS count").
+		Where("status IN ? AND assigned_agent_id = ?", model.ClaimableStatuses, 0).
+		Group("team_id").
+		Scan(&queued)
+
+	queuedByTeam := map[uint]int64{}
+	for _, row := range queued {
+		queuedByTeam[row.TeamID] = row.Count
+	}
+
+	data := []fiber.Map{}
+	for _, team := range teams {
+		var members, online int64
+		memberIDs := database.DB.Model(&model.TeamMember{}).Select("user_id").Where("team_id = ?", team.ID)
+		database.DB.Model(&model.TeamMember{}).Where("team_id = ?", team.ID).Count(&members)
+		tenantDB(c).Model(&model.User{}).
+			Where("id IN (?) AND availability = ?", memberIDs, model.AvailabilityOnline).
+			Count(&online)
+
+		statuses := byTeam[team.ID]
+		if statuses == nil {
+			statuses = map[string]int64{}
+		}
+
+		data = append(data, fiber.Map{
+			"team_id":        team.ID,
+			"name":           team.Name,
+			"slug":           team.Slug,
+			"members":        members,
+			"online_members": online,
+			"queued":         queuedByTeam[team.ID],
+			"by_status":      statuses,
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    data,
+	})
+}
@@ -0,0 +1,193 @@
+package controller
+
+import (
+	"backend/database"
+	"backend/model"
+	"backend/utils"
+	"fmt"
+
+	"github.com/gofiber/fiber/v3"
+	"gorm.io/gorm"
+)
+
+// tenantDB returns a handle whose queries on tenant-owned models are limited to
+// the caller's tenant. Super admins work across tenants.
+func tenantDB(c fiber.Ctx) *gorm.DB {
+	if superAdmin, _ := c.Locals("super_admin").(bool); superAdmin {
+		return database.DB
+	}
+	tenantID, _ := c.Locals("tenant_id").(uint)
+	return database.DB.WithContext(database.WithTenant(c.Context(), tenantID))
+}
+
+func currentTenantID(c fiber.Ctx) uint {
+	tenantID, _ := c.Locals("tenant_id").(uint)
+	return tenantID
+}
+
+func GetTenants(c fiber.Ctx) error {
+	var tenants []model.Tenant
+	if err := database.DB.Order("id ASC").Find(&tenants).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to fetch tenants",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    tenants,
+		"total":   len(tenants),
+	})
+}
+
+func GetTenantByID(c fiber.Ctx) error {
+	var tenant model.Tenant
+	if err := database.DB.First(&tenant, c.Params("id")).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Tenant not found",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    tenant,
+	})
+}
+
+func CreateTenant(c fiber.Ctx) error {
+	var req struct {
+		Name string `json:"name"`
+		Slug string `json:"slug"`
+	}
+
+	if err := c.Bind().Body(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	if req.Name == "" || req.Slug == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Name and slug are required",
+		})
+	}
+
+	tenant := model.Tenant{
+		Name:     req.Name,
+		Slug:     req.Slug,
+		IsActive: true,
+	}
+
+	if err := database.DB.Create(&tenant).Error; err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to create tenant: " + err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"success": true,
+		"message": "Tenant created successfully",
+		"data":    tenant,
+	})
+}
+
+func UpdateTenant(c fiber.Ctx) error {
+	var tenant model.Tenant
+	if err := database.DB.First(&tenant, c.Params("id")).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Tenant not found",
+		})
+	}
+
+	var req struct {
+		Name     *string `json:"name"`
+		Slug     *string `json:"slug"`
+		IsActive *bool   `json:"is_active"`
+	}
+
+	if err := c.Bind().Body(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	updates := map[string]interface{}{}
+	if req.Name != nil {
+		updates["name"] = *req.Name
+	}
+	if req.Slug != nil {
+		updates["slug"] = *req.Slug
+	}
+	if req.IsActive != nil {
+		updates["is_active"] = *req.IsActive
+	}
+
+	if len(updates) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "No fields to update",
+		})
+	}
+
+	if err := database.DB.Model(&tenant).Updates(updates).Error; err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to update tenant: " + err.Error(),
+		})
+	}
+
+	// JWTProtected caches the tenant status; drop it so deactivation is immediate.
+	utils.DeleteCache(fmt.Sprintf("tenant:status:%d", tenant.ID))
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Tenant updated successfully",
+		"data":    tenant,
+	})
+}
+
+func DeleteTenant(c fiber.Ctx) error {
+	var tenant model.Tenant
+	if err := database.DB.First(&tenant, c.Params("id")).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Tenant not found",
+		})
+	}
+
+	if tenant.ID == model.DefaultTenantID {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "The default tenant cannot be deleted",
+		})
+	}
+
+	var users int64
+	database.DB.Model(&model.User{}).Where("tenant_id = ?", tenant.ID).Count(&users)
+	if users > 0 {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error":   true,
+			"message": "Tenant still has users",
+		})
+	}
+
+	if err := database.DB.Delete(&tenant).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to delete tenant",
+		})
+	}
+	utils.DeleteCache(fmt.Sprintf("tenant:status:%d", tenant.ID))
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Tenant deleted successfully",
+	})
+}
@@ -0,0 +1,180 @@
+package controller
+
+import (
+	"backend/model"
+	"log"
+	"slices"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+	"gorm.io/gorm"
+)
+
+// recordEvent appends an event to the channel's timeline. A failed write is
+// logged rather than failing the change it describes.
+func recordEvent(db *gorm.DB, channel model.Channel, event model.ConversationEvent) {
+	event.TenantID = channel.TenantID
+	event.ChannelID = channel.ID
+	if err := db.Create(&event).Error; err != nil {
+		log.Printf("conversation event %s on channel %d: %v", event.Type, channel.ID, err)
+	}
+}
+
+// recordTransition records a status change that has already been applied to
+// channel, using the closed/reopened types where they apply.
+func recordTransition(db *gorm.DB, channel model.Channel, fromStatus string, actorID uint) {
+	eventType := model.EventStatusChanged
+	switch channel.Status {
+	case model.ChannelClosed:
+		eventType = model.EventClosed
+	case model.ChannelReopened:
+		eventType = model.EventReopened
+	}
+
+	recordEvent(db, channel, model.ConversationEvent{
+		Type:       eventType,
+		ActorID:    actorID,
+		FromStatus: fromStatus,
+		ToStatus:   channel.Status,
+	})
+}
+
+// GetChannelTimeline pages through messages, events and notes newest first.
+// The three sources have their own IDs, so the cursor is a timestamp: pass
+// cursor.before back as ?before= to fetch the next older page.
+func GetChannelTimeline(c fiber.Ctx) error {
+	channel, status, message := findStreamChannel(c)
+	if status != fiber.StatusOK {
+		return c.Status(status).JSON(fiber.Map{
+			"error":   true,
+			"message": message,
+		})
+	}
+
+	limit := defaultMessagePageSize
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   true,
+				"message": "limit must be a positive number",
+			})
+		}
+		limit = min(n, maxMessagePageSize)
+	}
+
+	var before *time.Time
+	if v := c.Query("before"); v != "" {
+		t, err := time.Parse(time.RFC3339Nano, v)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   true,
+				"message": "before must be an RFC 3339 timestamp",
+			})
+		}
+		before = &t
+	}
+
+	// Each source contributes at most limit+1 rows; the merged list is cut
+	// back to limit below.
+	page := func(query *gorm.DB, channelColumn string) *gorm.DB {
+		query = query.Where(channelColumn+" = ?", channel.ID)
+		if before != nil {
+			query = query.Where("created_at < ?", *before)
+		}
+		return query.Order("created_at DESC, id DESC").Limit(limit + 1)
+	}
+
+	var messages []model.Message
+	if err := page(tenantDB(c).Preload("Attachments"), "conversation_id").Find(&messages).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to fetch timeline",
+		})
+	}
+
+	var events []model.ConversationEvent
+	if err := page(tenantDB(c), "channel_id").Find(&events).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to fetch timeline",
+		})
+	}
+
+	var notes []model.Note
+	if err := page(tenantDB(c), "channel_id").Find(&notes).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to fetch timeline",
+		})
+	}
+
+	timeline := make([]fiber.Map, 0, len(messages)+len(events)+len(notes))
+	for _, m := range messages {
+		timeline = append(timeline, fiber.Map{
+			"kind":       "message",
+			"created_at": m.CreatedAt,
+			"message":    m,
+		})
+	}
+	for _, e := range events {
+		timeline = append(timeline, fiber.Map{
+			"kind":       "event",
+			"created_at": e.CreatedAt,
+			"event":      e,
+		})
+	}
+	for _, n := range notes {
+		timeline = append(timeline, fiber.Map{
+			"kind":       "note",
+			"created_at": n.CreatedAt,
+			"note":       n,
+		})
+	}
+
+	createdAt := func(i int) time.Time {
+		return timeline[i]["created_at"].(time.Time)
+	}
+
+	// Each source is already newest first, so a stable sort keeps same-time
+	// entries of one source in the order they were written.
+	sort.SliceStable(timeline, func(i, j int) bool {
+		return createdAt(i).After(createdAt(j))
+	})
+
+	hasOlder := len(timeline) > limit
+	if hasOlder {
+		// The cursor is exclusive, so a page must not end in the middle of
+		// entries sharing one timestamp; trim those back to the next page,
+		// unless the whole page shares it.
+		cut := limit
+		for cut > 0 && createdAt(cut-1).Equal(createdAt(limit)) {
+			cut--
+		}
+		if cut == 0 {
+			cut = limit
+		}
+		timeline = timeline[:cut]
+	}
+	slices.Reverse(timeline)
+
+	cursor := fiber.Map{
+		"before":    nil,
+		"has_older": hasOlder,
+	}
+	if len(timeline) > 0 {
+		cursor["before"] = createdAt(0).Format(time.RFC3339Nano)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data": fiber.Map{
+			"channel_id": channel.ID,
+			"status":     channel.Status,
+			"timeline":   timeline,
+			"cursor":     cursor,
+		},
+	})
+}
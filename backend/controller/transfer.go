@@ -0,0 +1,226 @@
+package controller
+
+import (
+	"backend/model"
+	"backend/utils"
+	"errors"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+	"gorm.io/gorm"
+)
+
+// moveChannel hands a channel to another agent (or back to the queue when
+// toAgentID is 0) and records the move. The update is guarded on the owner and
+// status that were read so a concurrent claim or move is never overwritten.
+// Extra column updates, such as a new team, are applied in the same statement;
+// record.ToTeamID names that team on the timeline event.
+func moveChannel(db *gorm.DB, channel *model.Channel, toAgentID uint, toStatus string, updates map[string]interface{}, record model.ChannelAssignment) error {
+	if err := model.CanTransition(channel.Status, toStatus); err != nil {
+		return err
+	}
+
+	if updates == nil {
+		updates = map[string]interface{}{}
+	}
+	updates["assigned_agent_id"] = toAgentID
+	updates["status"] = toStatus
+	updates["snoozed_until"] = nil
+	updates["updated_at"] = time.Now()
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&model.Channel{}).
+			Where("id = ? AND assigned_agent_id = ? AND status = ?", channel.ID, channel.AssignedAgentID, channel.Status).
+			Updates(updates)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errChannelConflict
+		}
+
+		record.ChannelID = channel.ID
+		record.FromAgentID = channel.AssignedAgentID
+		record.ToAgentID = toAgentID
+		if err := tx.Create(&record).Error; err != nil {
+			return err
+		}
+
+		eventType := model.EventReassigned
+		if record.Type == model.AssignmentTransfer {
+			eventType = model.EventTransferred
+		}
+		return tx.Create(&model.ConversationEvent{
+			TenantID:    channel.TenantID,
+			ChannelID:   channel.ID,
+			Type:        eventType,
+			ActorID:     record.AssignedBy,
+			FromStatus:  channel.Status,
+			ToStatus:    toStatus,
+			FromAgentID: channel.AssignedAgentID,
+			ToAgentID:   toAgentID,
+			ToTeamID:    record.ToTeamID,
+			Note:        record.Reason,
+		}).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	channel.AssignedAgentID = toAgentID
+	channel.Status = toStatus
+	return nil
+}
+
+func TransferChannel(c fiber.Ctx) error {
+	userID, ok := c.Locals("user_id").(uint)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   true,
+			"message": "Unauthorized",
+		})
+	}
+
+	role, _ := c.Locals("role").(string)
+
+	var req struct {
+		AgentID uint   `json:"agent_id"`
+		Queue   bool   `json:"queue"`
+		TeamID  *uint  `json:"team_id"`
+		Note    string `json:"note"`
+	}
+
+	if err := c.Bind().Body(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	// team_id on its own is a transfer to that team's queue; 0 is the
+	// tenant-wide queue.
+	toQueue := req.Queue || req.TeamID != nil
+	if (req.AgentID == 0) == !toQueue {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Provide either agent_id, or queue / team_id",
+		})
+	}
+
+	var updates map[string]interface{}
+	if req.TeamID != nil {
+		if *req.TeamID != 0 {
+			var team model.Team
+			if err := tenantDB(c).First(&team, *req.TeamID).Error; err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"error":   true,
+					"message": "Team not found",
+				})
+			}
+		}
+		updates = map[string]interface{}{"team_id": *req.TeamID}
+	}
+
+	var channel model.Channel
+	query := tenantDB(c)
+	if role == "agent" {
+		query = query.Where("assigned_agent_id = ?", userID)
+	}
+
+	if err := query.First(&channel, c.Params("id")).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Channel not found or not assigned to you",
+		})
+	}
+
+	toStatus := model.ChannelOpen
+	if req.AgentID != 0 {
+		if req.AgentID == channel.AssignedAgentID {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   true,
+				"message": "Channel is already assigned to this agent",
+			})
+		}
+
+		var target model.User
+		if err := tenantDB(c).Where("role = ? AND is_active = ?", model.RoleAgent, true).
+			First(&target, req.AgentID).Error; err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   true,
+				"message": "Target agent not found",
+			})
+		}
+		toStatus = model.ChannelAssigned
+	}
+
+	previousAgentID := channel.AssignedAgentID
+
+	err := moveChannel(tenantDB(c), &channel, req.AgentID, toStatus, updates, model.ChannelAssignment{
+		Type:       model.AssignmentTransfer,
+		AssignedBy: userID,
+		Reason:     req.Note,
+		ToTeamID:   req.TeamID,
+	})
+	if errors.Is(err, errChannelConflict) {
+		tenantDB(c).First(&channel, channel.ID)
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error":   true,
+			"message": "Channel was changed by someone else. Please retry.",
+			"data": fiber.Map{
+				"channel_id":        channel.ID,
+				"assigned_agent_id": channel.AssignedAgentID,
+				"status":            channel.Status,
+			},
+		})
+	}
+	if err != nil {
+		return transitionErrorResponse(c, err)
+	}
+
+	invalidateChannelCache(channel.ID)
+	invalidateAgentConversationsCache(previousAgentID)
+	invalidateAgentConversationsCache(req.AgentID)
+	invalidateUserConversationsCache(channel.CustomerID)
+	invalidateAvailableChannelsCache(channel.TenantID)
+
+	transfer := fiber.Map{
+		"channel_id":     channel.ID,
+		"from_agent_id":  previousAgentID,
+		"to_agent_id":    req.AgentID,
+		"transferred_by": userID,
+		"note":           req.Note,
+		"status":         channel.Status,
+	}
+	if req.TeamID != nil {
+		transfer["team_id"] = *req.TeamID
+	}
+
+	// The handover note is internal, so the full payload only goes to the
+	// agents involved; customers on the channel topic just see the new owner.
+	// A team transfer notifies every member, since any of them may claim it.
+	if req.AgentID != 0 {
+		utils.PublishEvent(utils.AgentTopic(req.AgentID), "transfer", transfer)
+	}
+	if req.TeamID != nil && *req.TeamID != 0 {
+		for _, memberID := range teamMemberIDs(*req.TeamID) {
+			if memberID != previousAgentID {
+				utils.PublishEvent(utils.AgentTopic(memberID), "transfer", transfer)
+			}
+		}
+	}
+	if previousAgentID != 0 && previousAgentID != req.AgentID {
+		utils.PublishEvent(utils.AgentTopic(previousAgentID), "transfer", transfer)
+	}
+	utils.PublishEvent(utils.ChannelTopic(channel.ID), "transfer", fiber.Map{
+		"channel_id":        channel.ID,
+		"assigned_agent_id": req.AgentID,
+		"status":            channel.Status,
+	})
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Channel transferred successfully",
+		"data":    transfer,
+	})
+}
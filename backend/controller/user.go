@@ -1,8 +1,10 @@
 package controller
 
 import (
-	"backend/database"
 	"backend/model"
+	"backend/utils"
+	"fmt"
+	"strconv"
 
 	"github.com/gofiber/fiber/v3"
 )
@@ -17,7 +19,7 @@ func GetProfile(c fiber.Ctx) error {
 	}
 
 	var user model.User
-	if err := database.DB.First(&user, userID).Error; err != nil {
+	if err := tenantDB(c).First(&user, userID).Error; err != nil {
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
 			"error":   true,
 			"message": "User not found",
@@ -41,9 +43,40 @@ func GetAllUsers(c fiber.Ctx) error {
 		})
 	}
 
+	limitInt, _ := strconv.Atoi(c.Query("limit", "20"))
+	offsetInt, _ := strconv.Atoi(c.Query("offset", "0"))
+	if limitInt <= 0 || limitInt > 100 {
+		limitInt = 20
+	}
+
+	query := tenantDB(c).Model(&model.User{})
+
+	if c.Query("deleted") == "true" {
+		query = query.Unscoped().Where("deleted_at IS NOT NULL")
+	}
+	if filterRole := c.Query("role"); filterRole != "" {
+		query = query.Where("role = ?", filterRole)
+	}
+	if tenantID := c.Query("tenant_id"); tenantID != "" {
+		query = query.Where("tenant_id = ?", tenantID)
+	}
+	if isActive := c.Query("is_active"); isActive != "" {
+		query = query.Where("is_active = ?", isActive == "true")
+	}
+	if search := c.Query("q"); search != "" {
+		query = query.Where("email LIKE ?", "%"+search+"%")
+	}
+
+	var total int64
+	query.Count(&total)
+
 	var users []model.User
 
-	if err := database.DB.Select("id, email, full_name, role, created_at, updated_at").Find(&users).Error; err != nil {
+	if err := query.Select("id, tenant_id, email, full_name, phone, role, is_active, skills, last_login_at, created_at, updated_at, deleted_at").
+		Order("id ASC").
+		Limit(limitInt).
+		Offset(offsetInt).
+		Find(&users).Error; err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error":   true,
 			"message": "Failed to fetch users",
@@ -53,6 +86,391 @@ func GetAllUsers(c fiber.Ctx) error {
 	return c.JSON(fiber.Map{
 		"success": true,
 		"data":    users,
-		"total":   len(users),
+		"total":   total,
+		"pagination": fiber.Map{
+			"total":  total,
+			"limit":  limitInt,
+			"offset": offsetInt,
+		},
 	})
 }
+
+func CreateUser(c fiber.Ctx) error {
+	var req struct {
+		Email    string `json:"email"`
+		Password string `json:"password"`
+		FullName string `json:"full_name"`
+		Phone    string `json:"phone"`
+		Role     string `json:"role"`
+		TenantID uint   `json:"tenant_id"`
+	}
+
+	if err := c.Bind().Body(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	if req.Email == "" || req.Password == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Email and password are required",
+		})
+	}
+
+	if err := utils.ValidatePassword(req.Password); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": err.Error(),
+		})
+	}
+
+	if req.Role == "" {
+		req.Role = "user"
+	}
+
+	if req.Role != string(model.RoleUser) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Agent and admin accounts must be created through an invitation",
+		})
+	}
+
+	if superAdmin, _ := c.Locals("super_admin").(bool); !superAdmin || req.TenantID == 0 {
+		req.TenantID = currentTenantID(c)
+	}
+
+	user := model.User{
+		TenantID:     req.TenantID,
+		Email:        req.Email,
+		PasswordHash: utils.GeneratePassword(req.Password),
+		FullName:     req.FullName,
+		Phone:        req.Phone,
+		Role:         model.Role(req.Role),
+		IsActive:     true,
+	}
+
+	if err := tenantDB(c).Create(&user).Error; err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to create user: " + err.Error(),
+		})
+	}
+
+	user.PasswordHash = ""
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"success": true,
+		"message": "User created successfully",
+		"data":    user,
+	})
+}
+
+func UpdateUser(c fiber.Ctx) error {
+	user, err := findUserByParam(c, false)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "User not found",
+		})
+	}
+
+	var req struct {
+		Email    *string `json:"email"`
+		FullName *string `json:"full_name"`
+		Phone    *string `json:"phone"`
+		Avatar   *string `json:"avatar"`
+		TenantID *uint   `json:"tenant_id"`
+		Skills   *string `json:"skills"`
+	}
+
+	if err := c.Bind().Body(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	updates := map[string]interface{}{}
+	if req.Email != nil {
+		updates["email"] = *req.Email
+	}
+	if req.FullName != nil {
+		updates["full_name"] = *req.FullName
+	}
+	if req.Phone != nil {
+		updates["phone"] = *req.Phone
+	}
+	if req.Avatar != nil {
+		updates["avatar"] = *req.Avatar
+	}
+	if req.Skills != nil {
+		updates["skills"] = *req.Skills
+	}
+	if req.TenantID != nil {
+		if superAdmin, _ := c.Locals("super_admin").(bool); !superAdmin {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error":   true,
+				"message": "Only a super admin can move users between tenants",
+			})
+		}
+		updates["tenant_id"] = *req.TenantID
+	}
+
+	if len(updates) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "No fields to update",
+		})
+	}
+
+	if err := tenantDB(c).Model(&user).Updates(updates).Error; err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to update user: " + err.Error(),
+		})
+	}
+
+	invalidateUserTokens(user.ID)
+
+	user.PasswordHash = ""
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "User updated successfully",
+		"data":    user,
+	})
+}
+
+func DeactivateUser(c fiber.Ctx) error {
+	return setUserActive(c, false)
+}
+
+func ActivateUser(c fiber.Ctx) error {
+	return setUserActive(c, true)
+}
+
+func DeleteUser(c fiber.Ctx) error {
+	user, err := findUserByParam(c, false)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "User not found",
+		})
+	}
+
+	if isSelf(c, user.ID) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "You cannot delete your own account",
+		})
+	}
+
+	if err := tenantDB(c).Delete(&user).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to delete user",
+		})
+	}
+
+	invalidateUserTokens(user.ID)
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "User deleted successfully",
+	})
+}
+
+func RestoreUser(c fiber.Ctx) error {
+	user, err := findUserByParam(c, true)
+	if err != nil || !user.DeletedAt.Valid {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "Deleted user not found",
+		})
+	}
+
+	if err := tenantDB(c).Unscoped().Model(&user).Update("deleted_at", nil).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to restore user",
+		})
+	}
+
+	invalidateUserTokens(user.ID)
+
+	user.PasswordHash = ""
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "User restored successfully",
+		"data":    user,
+	})
+}
+
+func ChangeUserRole(c fiber.Ctx) error {
+	user, err := findUserByParam(c, false)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "User not found",
+		})
+	}
+
+	var req struct {
+		Role string `json:"role"`
+	}
+
+	if err := c.Bind().Body(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	if !isValidRole(req.Role) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid role. Must be admin, agent, or user",
+		})
+	}
+
+	if isSelf(c, user.ID) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "You cannot change your own role",
+		})
+	}
+
+	if err := tenantDB(c).Model(&user).Update("role", model.Role(req.Role)).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to change role",
+		})
+	}
+
+	invalidateUserTokens(user.ID)
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Role changed successfully",
+		"data": fiber.Map{
+			"user_id": user.ID,
+			"role":    req.Role,
+		},
+	})
+}
+
+func ResetUserPassword(c fiber.Ctx) error {
+	user, err := findUserByParam(c, false)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "User not found",
+		})
+	}
+
+	var req struct {
+		Password string `json:"password"`
+	}
+
+	if err := c.Bind().Body(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid request body",
+		})
+	}
+
+	if err := utils.ValidatePassword(req.Password); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": err.Error(),
+		})
+	}
+
+	if err := tenantDB(c).Model(&user).Update("password_hash", utils.GeneratePassword(req.Password)).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to reset password",
+		})
+	}
+
+	invalidateUserTokens(user.ID)
+	utils.ResetFailedLogin(user.Email)
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Password reset successfully",
+	})
+}
+
+func setUserActive(c fiber.Ctx, active bool) error {
+	user, err := findUserByParam(c, false)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   true,
+			"message": "User not found",
+		})
+	}
+
+	if !active && isSelf(c, user.ID) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   true,
+			"message": "You cannot deactivate your own account",
+		})
+	}
+
+	if err := tenantDB(c).Model(&user).Update("is_active", active).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   true,
+			"message": "Failed to update user status",
+		})
+	}
+
+	invalidateUserTokens(user.ID)
+
+	message := "User deactivated successfully"
+	if active {
+		message = "User activated successfully"
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": message,
+		"data": fiber.Map{
+			"user_id":   user.ID,
+			"is_active": active,
+		},
+	})
+}
+
+func findUserByParam(c fiber.Ctx, withDeleted bool) (model.User, error) {
+	var user model.User
+	query := tenantDB(c)
+	if withDeleted {
+		query = query.Unscoped()
+	}
+	err := query.First(&user, c.Params("id")).Error
+	return user, err
+}
+
+func isSelf(c fiber.Ctx, userID uint) bool {
+	currentID, _ := c.Locals("user_id").(uint)
+	return currentID == userID
+}
+
+func isValidRole(role string) bool {
+	switch model.Role(role) {
+	case model.RoleAdmin, model.RoleAgent, model.RoleUser:
+		return true
+	}
+	return false
+}
+
+// invalidateUserTokens logs the user out everywhere after an admin change and
+// drops the cached account status read by JWTProtected.
+func invalidateUserTokens(userID uint) {
+	revokeAllSessions(userID)
+	utils.DeleteCache(fmt.Sprintf("user:status:%d", userID))
+}
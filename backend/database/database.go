@@ -2,8 +2,11 @@ package database
 
 import (
 	"backend/model"
+	"errors"
 	"fmt"
+	"log"
 
+	mysqldriver "github.com/go-sql-driver/mysql"
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
 )
@@ -16,7 +19,64 @@ func ConnectionDB() {
 	if err != nil {
 		panic("Failed to connect to database!")
 	}
-	defer db.AutoMigrate(&model.User{}, &model.Channel{}, &model.Message{}, &model.BlacklistedToken{})
+	// blacklisted_tokens used to be keyed by the full token string. Tokens from
+	// before the upgrade carry no jti and JWTProtected rejects them outright, so
+	// the old rows can never match; drop the table and let AutoMigrate rebuild it.
+	if db.Migrator().HasColumn(&model.BlacklistedToken{}, "token") {
+		db.Migrator().DropTable(&model.BlacklistedToken{})
+	}
+	// Routing only picks online agents; agents that predate the availability
+	// column start online instead of at the column's offline default.
+	backfillAvailability := db.Migrator().HasTable(&model.User{}) && !db.Migrator().HasColumn(&model.User{}, "availability")
+	db.AutoMigrate(&model.Tenant{}, &model.User{}, &model.Channel{}, &model.Message{}, &model.BlacklistedToken{}, &model.Session{}, &model.Invitation{}, &model.RoutingSettings{}, &model.ChannelAssignment{}, &model.ConversationEvent{}, &model.Note{}, &model.Tag{}, &model.SavedReply{}, &model.Attachment{}, &model.ReadCursor{}, &model.Team{}, &model.TeamMember{}, &model.SLAPolicy{})
+	// AutoMigrate does not widen an existing enum, so apply the status set explicitly.
+	db.Migrator().AlterColumn(&model.Channel{}, "Status")
+	// routing_settings.tenant_id was created AUTO_INCREMENT; the value always
+	// comes from the tenant, so redefine it as a plain key.
+	db.Migrator().AlterColumn(&model.RoutingSettings{}, "TenantID")
+	migrateReadFlags(db)
+	if backfillAvailability {
+		db.Model(&model.User{}).Where("role = ?", model.RoleAgent).Update("availability", model.AvailabilityOnline)
+	}
+	db.FirstOrCreate(&model.Tenant{ID: model.DefaultTenantID}, model.Tenant{ID: model.DefaultTenantID, Name: "Default", Slug: "default"})
+	registerTenantCallbacks(db)
 	fmt.Println("Database terkoneksi & migrasi berhasil!")
 	DB = db
 }
+
+// migrateReadFlags seeds read_cursors from the old messages.is_read flag, then
+// drops the column so it runs once. The flag was shared, so a read customer
+// message means the assigned agent read it and a read agent message means the
+// customer did; each side has also read its own messages.
+func migrateReadFlags(db *gorm.DB) {
+	if !db.Migrator().HasColumn(&model.Message{}, "is_read") {
+		return
+	}
+
+	backfill := `INSERT INTO read_cursors (channel_id, user_id, tenant_id, last_read_message_id, updated_at)
+		SELECT channels.id, channels.%[1]s, channels.tenant_id, MAX(messages.id), NOW()
+		FROM channels JOIN messages ON messages.conversation_id = channels.id
+		WHERE channels.%[1]s <> 0 AND (messages.sender_type = ? OR messages.is_read = 1)
+		GROUP BY channels.id, channels.%[1]s, channels.tenant_id
+		ON DUPLICATE KEY UPDATE last_read_message_id = GREATEST(last_read_message_id, VALUES(last_read_message_id))`
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(fmt.Sprintf(backfill, "assigned_agent_id"), "agent").Error; err != nil {
+			return err
+		}
+		return tx.Exec(fmt.Sprintf(backfill, "customer_id"), "customer").Error
+	})
+	if err != nil {
+		log.Printf("read cursor migration: %v", err)
+		return
+	}
+
+	db.Migrator().DropColumn(&model.Message{}, "is_read")
+}
+
+// IsDuplicateKey reports whether err is MySQL's duplicate entry error, so a
+// unique index violation can be told apart from other failures.
+func IsDuplicateKey(err error) bool {
+	var mysqlErr *mysqldriver.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == 1062
+}
@@ -0,0 +1,63 @@
+package database
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type tenantKey struct{}
+
+func WithTenant(ctx context.Context, tenantID uint) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenantID)
+}
+
+func TenantFromContext(ctx context.Context) (uint, bool) {
+	tenantID, ok := ctx.Value(tenantKey{}).(uint)
+	return tenantID, ok
+}
+
+// registerTenantCallbacks scopes every statement whose model has a TenantID
+// field to the tenant carried in the statement context, and stamps that
+// tenant on new rows. Statements without a tenant in context are left alone.
+func registerTenantCallbacks(db *gorm.DB) {
+	db.Callback().Query().Before("gorm:query").Register("tenant:query", scopeToTenant)
+	db.Callback().Row().Before("gorm:row").Register("tenant:row", scopeToTenant)
+	db.Callback().Update().Before("gorm:update").Register("tenant:update", scopeToTenant)
+	db.Callback().Delete().Before("gorm:delete").Register("tenant:delete", scopeToTenant)
+	db.Callback().Create().Before("gorm:create").Register("tenant:create", stampTenant)
+}
+
+func scopeToTenant(db *gorm.DB) {
+	tenantID, ok := TenantFromContext(db.Statement.Context)
+	if !ok || db.Statement.Schema == nil {
+		return
+	}
+
+	field := db.Statement.Schema.LookUpField("TenantID")
+	if field == nil {
+		return
+	}
+
+	db.Statement.AddClause(clause.Where{Exprs: []clause.Expression{
+		clause.Eq{
+			Column: clause.Column{Table: clause.CurrentTable, Name: field.DBName},
+			Value:  tenantID,
+		},
+	}})
+}
+
+func stampTenant(db *gorm.DB) {
+	tenantID, ok := TenantFromContext(db.Statement.Context)
+	if !ok || db.Statement.Schema == nil {
+		return
+	}
+
+	field := db.Statement.Schema.LookUpField("TenantID")
+	if field == nil {
+		return
+	}
+
+	db.Statement.SetColumn(field.DBName, tenantID, true)
+}
@@ -2,19 +2,29 @@ package main
 
 import (
 	"backend/config"
+	"backend/controller"
 	"backend/database"
 	"backend/router"
+	"backend/routing"
+	"backend/search"
+	"backend/storage"
+	"backend/utils"
 	"log"
+	"time"
 
 	"github.com/gofiber/fiber/v3"
 	"github.com/gofiber/fiber/v3/middleware/cors"
 )
 
 func main() {
-	app := fiber.New()
+	// Attachments are capped per file in the controller; this only bounds the
+	// whole multipart request.
+	app := fiber.New(fiber.Config{
+		BodyLimit: 60 << 20,
+	})
 
 	app.Use(cors.New(cors.Config{
-		AllowOrigins:     []string{"http://localhost:3000"},
+		AllowOrigins:     config.AllowedOrigins(),
 		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "PATCH", "OPTIONS"},
 		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization"},
 		AllowCredentials: true,
@@ -24,6 +34,17 @@ func main() {
 
 	config.InitRedis()
 
+	storage.Init()
+	search.SetIndexer(search.NewMySQL(database.DB))
+
+	utils.InitTokenStore(database.DB)
+	utils.StartTokenSweeper(database.DB, time.Hour)
+	routing.StartWorker(database.DB, 10*time.Second, controller.OnChannelRouted)
+	controller.StartSnoozeWaker(time.Minute)
+	controller.StartAvailabilitySweeper(time.Minute)
+	utils.StartPresenceSweeper(15 * time.Second)
+	controller.StartSLAChecker(time.Minute)
+
 	router.SetupRoutes(app)
 
 	log.Fatal(app.Listen(":8000"))
@@ -1,24 +1,89 @@
 package middleware
 
 import (
+	"backend/config"
 	"backend/database"
 	"backend/model"
 	"backend/utils"
+	"fmt"
 	"strings"
+	"time"
 
 	"github.com/gofiber/fiber/v3"
 	"github.com/golang-jwt/jwt/v5"
 )
 
-func isTokenBlacklisted(tokenString string) bool {
-	var blacklistedToken model.BlacklistedToken
-	result := database.DB.Where("token = ?", tokenString).First(&blacklistedToken)
-	return result.Error == nil
+func isTokenRevoked(claims jwt.MapClaims) bool {
+	jti, _ := claims["jti"].(string)
+	if jti == "" || utils.Revocations.IsRevoked(jti) {
+		return true
+	}
+
+	if sessionID, _ := claims["sid"].(string); sessionID != "" && utils.Revocations.IsRevoked("session:"+sessionID) {
+		return true
+	}
+
+	userID, _ := claims["user_id"].(float64)
+	issuedAt, _ := claims["iat"].(float64)
+	return utils.Revocations.IsUserRevoked(uint(userID), time.Unix(int64(issuedAt), 0))
+}
+
+// isUserActive rejects deactivated or deleted accounts; the result is cached
+// briefly and dropped whenever an admin changes the account.
+func isUserActive(userID uint) bool {
+	key := fmt.Sprintf("user:status:%d", userID)
+
+	var active bool
+	if err := utils.GetCache(key, &active); err == nil {
+		return active
+	}
+
+	var user model.User
+	active = database.DB.Select("id", "is_active").First(&user, userID).Error == nil && user.IsActive
+	utils.SetCache(key, active, time.Minute)
+
+	return active
+}
+
+// isTenantActive rejects tokens of deactivated or deleted tenants, cached the
+// same way as the account status and dropped when the tenant is updated.
+func isTenantActive(tenantID uint) bool {
+	key := fmt.Sprintf("tenant:status:%d", tenantID)
+
+	var active bool
+	if err := utils.GetCache(key, &active); err == nil {
+		return active
+	}
+
+	var tenant model.Tenant
+	active = database.DB.Select("id", "is_active").First(&tenant, tenantID).Error == nil && tenant.IsActive
+	utils.SetCache(key, active, time.Minute)
+
+	return active
+}
+
+// touchSession records session activity at most once a minute per session.
+func touchSession(sessionID string) {
+	key := fmt.Sprintf("session:touch:%s", sessionID)
+	if ok, err := config.RedisClient.SetNX(config.Ctx, key, 1, time.Minute).Result(); err != nil || !ok {
+		return
+	}
+	database.DB.Model(&model.Session{}).Where("id = ?", sessionID).Update("last_used_at", time.Now())
+}
+
+// isStreamRequest reports whether the client cannot send an Authorization
+// header (browser WebSocket and EventSource), so the token may come from the
+// query string.
+func isStreamRequest(c fiber.Ctx) bool {
+	return c.IsWebSocket() || strings.Contains(c.Get("Accept"), "text/event-stream")
 }
 
 func JWTProtected(allowedRoles ...string) fiber.Handler {
 	return func(c fiber.Ctx) error {
 		authHeader := c.Get("Authorization")
+		if authHeader == "" && isStreamRequest(c) && c.Query("token") != "" {
+			authHeader = "Bearer " + c.Query("token")
+		}
 		if authHeader == "" {
 			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
 				"error":   true,
@@ -35,13 +100,6 @@ func JWTProtected(allowedRoles ...string) fiber.Handler {
 		}
 		tokenString := parts[1]
 
-		if isTokenBlacklisted(tokenString) {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"error":   true,
-				"message": "Token has been revoked. Please login again.",
-			})
-		}
-
 		var claims jwt.MapClaims
 		var tokenRole string
 		var err error
@@ -61,14 +119,44 @@ func JWTProtected(allowedRoles ...string) fiber.Handler {
 			})
 		}
 
+		if isTokenRevoked(claims) {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error":   true,
+				"message": "Token has been revoked. Please login again.",
+			})
+		}
+
 		c.Locals("token", tokenString)
 		c.Locals("user", claims)
 		c.Locals("role", tokenRole)
 
 		if userID, ok := claims["user_id"].(float64); ok {
+			if !isUserActive(uint(userID)) {
+				return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+					"error":   true,
+					"message": "Account has been deactivated",
+				})
+			}
 			c.Locals("user_id", uint(userID))
 		}
 
+		if tenantID, ok := claims["tenant_id"].(float64); ok {
+			if !isTenantActive(uint(tenantID)) {
+				return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+					"error":   true,
+					"message": "Tenant is not active",
+				})
+			}
+			c.Locals("tenant_id", uint(tenantID))
+		}
+		superAdmin, _ := claims["super_admin"].(bool)
+		c.Locals("super_admin", superAdmin)
+
+		if sessionID, ok := claims["sid"].(string); ok && sessionID != "" {
+			c.Locals("session_id", sessionID)
+			touchSession(sessionID)
+		}
+
 		return c.Next()
 	}
 }
@@ -82,9 +170,9 @@ func BlacklistCheckMiddleware() fiber.Handler {
 
 		parts := strings.Split(authHeader, " ")
 		if len(parts) == 2 && parts[0] == "Bearer" {
-			tokenString := parts[1]
+			claims, err := utils.ParseToken(parts[1])
 
-			if isTokenBlacklisted(tokenString) {
+			if err == nil && isTokenRevoked(claims) {
 				return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
 					"error":   true,
 					"message": "Token has been revoked. Please login again.",
@@ -108,6 +196,19 @@ func UserProtected() fiber.Handler {
 	return JWTProtected("user")
 }
 
+// SuperAdminProtected must run after AdminProtected, which sets the claims.
+func SuperAdminProtected() fiber.Handler {
+	return func(c fiber.Ctx) error {
+		if superAdmin, _ := c.Locals("super_admin").(bool); !superAdmin {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error":   true,
+				"message": "Access denied. Super admin only.",
+			})
+		}
+		return c.Next()
+	}
+}
+
 func AdminOrAgentProtected() fiber.Handler {
 	return JWTProtected("admin", "agent")
 }
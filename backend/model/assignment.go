@@ -0,0 +1,24 @@
+package model
+
+import "time"
+
+const (
+	AssignmentReassign = "reassign"
+	AssignmentTransfer = "transfer"
+)
+
+type ChannelAssignment struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	ChannelID   uint      `gorm:"index;not null" json:"channel_id"`
+	Type        string    `gorm:"size:20;default:'reassign'" json:"type"`
+	FromAgentID uint      `json:"from_agent_id"`
+	ToAgentID   uint      `json:"to_agent_id"`
+	ToTeamID    *uint     `json:"to_team_id,omitempty"`
+	AssignedBy  uint      `json:"assigned_by"`
+	Reason      string    `gorm:"type:text" json:"reason"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+func (ChannelAssignment) TableName() string {
+	return "channel_assignments"
+}
@@ -0,0 +1,46 @@
+package model
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type Attachment struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	TenantID     uint      `gorm:"index" json:"tenant_id"`
+	ChannelID    uint      `gorm:"index;not null" json:"channel_id"`
+	MessageID    uint      `gorm:"index;not null" json:"message_id"`
+	FileName     string    `gorm:"size:255" json:"file_name"`
+	MimeType     string    `gorm:"size:100" json:"mime_type"`
+	Size         int64     `json:"size"`
+	StorageKey   string    `gorm:"size:255;not null" json:"-"`
+	ThumbnailKey string    `gorm:"size:255" json:"-"`
+	URL          string    `gorm:"-" json:"url"`
+	ThumbnailURL string    `gorm:"-" json:"thumbnail_url,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+func (Attachment) TableName() string {
+	return "attachments"
+}
+
+// Download URLs go through the API so every fetch is checked against the
+// channel's access rules.
+func (a *Attachment) setURLs() {
+	a.URL = fmt.Sprintf("/api/attachments/%d", a.ID)
+	if a.ThumbnailKey != "" {
+		a.ThumbnailURL = a.URL + "?thumbnail=1"
+	}
+}
+
+func (a *Attachment) AfterFind(tx *gorm.DB) error {
+	a.setURLs()
+	return nil
+}
+
+func (a *Attachment) AfterCreate(tx *gorm.DB) error {
+	a.setURLs()
+	return nil
+}
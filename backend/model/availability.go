@@ -0,0 +1,19 @@
+package model
+
+// Agent availability is stored on User.Availability. Only online agents take
+// new work; User.MaxConcurrent caps their active chats, with 0 meaning the
+// tenant's max_chats_per_agent.
+const (
+	AvailabilityOnline  = "online"
+	AvailabilityBusy    = "busy"
+	AvailabilityAway    = "away"
+	AvailabilityOffline = "offline"
+)
+
+func IsValidAvailability(availability string) bool {
+	switch availability {
+	case AvailabilityOnline, AvailabilityBusy, AvailabilityAway, AvailabilityOffline:
+		return true
+	}
+	return false
+}
@@ -8,7 +8,7 @@ import (
 
 type BlacklistedToken struct {
 	gorm.Model
-	Token     string    `gorm:"type:text;uniqueIndex;not null"`
+	JTI       string    `gorm:"column:jti;size:64;uniqueIndex;not null"`
 	ExpiresAt time.Time `gorm:"index;not null"`
 }
 
@@ -5,13 +5,25 @@ import (
 )
 
 type Channel struct {
-	ID              uint      `gorm:"primaryKey" json:"id"`
-	TenantID        uint      `json:"tenant_id"`
-	CustomerID      uint      `json:"customer_id"`
-	Status          string    `gorm:"type:enum('open','assigned','closed');default:'open'" json:"status"`
-	AssignedAgentID uint      `json:"assigned_agent_id"`
-	CreatedAt       time.Time `json:"created_at"`
-	UpdatedAt       time.Time `json:"updated_at"`
+	ID              uint       `gorm:"primaryKey" json:"id"`
+	TenantID        uint       `json:"tenant_id"`
+	CustomerID      uint       `json:"customer_id"`
+	Status          string     `gorm:"type:enum('open','assigned','pending','snoozed','closed','reopened');default:'open'" json:"status"`
+	AssignedAgentID uint       `json:"assigned_agent_id"`
+	TeamID          uint       `gorm:"index" json:"team_id"`
+	Skill           string     `gorm:"size:100" json:"skill"`
+	SnoozedUntil    *time.Time `gorm:"index" json:"snoozed_until"`
+
+	SLAPolicyID             uint       `json:"sla_policy_id"`
+	FirstResponseDueAt      *time.Time `gorm:"index" json:"first_response_due_at"`
+	FirstRespondedAt        *time.Time `json:"first_responded_at"`
+	FirstResponseBreachedAt *time.Time `json:"first_response_breached_at"`
+	ResolutionDueAt         *time.Time `gorm:"index" json:"resolution_due_at"`
+	ResolutionBreachedAt    *time.Time `json:"resolution_breached_at"`
+
+	Tags      []Tag     `gorm:"many2many:channel_tags" json:"tags,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
 }
 
 func (Channel) TableName() string {
@@ -0,0 +1,51 @@
+package model
+
+import "fmt"
+
+const (
+	ChannelOpen     = "open"
+	ChannelAssigned = "assigned"
+	ChannelPending  = "pending"
+	ChannelSnoozed  = "snoozed"
+	ChannelClosed   = "closed"
+	ChannelReopened = "reopened"
+)
+
+// channelTransitions is the single source of truth for how a conversation may
+// move between states. assigned -> assigned covers reassignment and transfer,
+// and -> open covers handing a conversation back to the queue.
+var channelTransitions = map[string][]string{
+	ChannelOpen:     {ChannelAssigned, ChannelClosed},
+	ChannelAssigned: {ChannelOpen, ChannelAssigned, ChannelPending, ChannelSnoozed, ChannelClosed},
+	ChannelPending:  {ChannelOpen, ChannelAssigned, ChannelSnoozed, ChannelClosed},
+	ChannelSnoozed:  {ChannelOpen, ChannelAssigned, ChannelPending, ChannelClosed},
+	ChannelClosed:   {ChannelReopened},
+	ChannelReopened: {ChannelOpen, ChannelAssigned, ChannelPending, ChannelSnoozed, ChannelClosed},
+}
+
+type TransitionError struct {
+	From string
+	To   string
+}
+
+func (e *TransitionError) Error() string {
+	return fmt.Sprintf("cannot change conversation status from %s to %s", e.From, e.To)
+}
+
+func IsValidChannelStatus(status string) bool {
+	_, ok := channelTransitions[status]
+	return ok
+}
+
+func CanTransition(from, to string) error {
+	for _, allowed := range channelTransitions[from] {
+		if allowed == to {
+			return nil
+		}
+	}
+	return &TransitionError{From: from, To: to}
+}
+
+// ClaimableStatuses are the states in which a channel without an agent waits
+// in the queue.
+var ClaimableStatuses = []string{ChannelOpen, ChannelReopened}
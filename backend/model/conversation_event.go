@@ -0,0 +1,38 @@
+package model
+
+import "time"
+
+const (
+	EventCreated       = "created"
+	EventAssigned      = "assigned"
+	EventRouted        = "routed"
+	EventReassigned    = "reassigned"
+	EventTransferred   = "transferred"
+	EventStatusChanged = "status_changed"
+	EventClosed        = "closed"
+	EventReopened      = "reopened"
+	EventTagged        = "tagged"
+	EventUntagged      = "untagged"
+	EventSLABreached   = "sla_breached"
+)
+
+// ConversationEvent is an append-only record of something that happened to a
+// channel. ActorID is 0 for changes made by the system (routing, snooze waker).
+type ConversationEvent struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	TenantID    uint      `gorm:"index" json:"tenant_id"`
+	ChannelID   uint      `gorm:"index;not null" json:"channel_id"`
+	Type        string    `gorm:"size:30;not null" json:"type"`
+	ActorID     uint      `json:"actor_id"`
+	FromStatus  string    `gorm:"size:20" json:"from_status,omitempty"`
+	ToStatus    string    `gorm:"size:20" json:"to_status,omitempty"`
+	FromAgentID uint      `json:"from_agent_id,omitempty"`
+	ToAgentID   uint      `json:"to_agent_id,omitempty"`
+	ToTeamID    *uint     `json:"to_team_id,omitempty"`
+	Note        string    `gorm:"type:text" json:"note,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+func (ConversationEvent) TableName() string {
+	return "conversation_events"
+}
@@ -0,0 +1,21 @@
+package model
+
+import "time"
+
+type Invitation struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	TenantID   uint       `gorm:"index;default:1" json:"tenant_id"`
+	Email      string     `gorm:"index;not null" json:"email"`
+	FullName   string     `json:"full_name"`
+	Role       Role       `gorm:"type:enum('admin','agent','user');default:'agent'" json:"role"`
+	TokenHash  string     `gorm:"size:64;uniqueIndex;not null" json:"-"`
+	InvitedBy  uint       `json:"invited_by"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	AcceptedAt *time.Time `json:"accepted_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+func (Invitation) TableName() string {
+	return "invitations"
+}
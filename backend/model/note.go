@@ -0,0 +1,19 @@
+package model
+
+import "time"
+
+// Note is an internal remark left on a channel by an agent or admin. Notes are
+// kept apart from messages so they can never reach the customer.
+type Note struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	TenantID  uint      `gorm:"index" json:"tenant_id"`
+	ChannelID uint      `gorm:"index;not null" json:"channel_id"`
+	AuthorID  uint      `gorm:"not null" json:"author_id"`
+	Body      string    `gorm:"type:text;not null" json:"body"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (Note) TableName() string {
+	return "notes"
+}
@@ -0,0 +1,17 @@
+package model
+
+import "time"
+
+// ReadCursor is how far one participant has read a channel. Every message with
+// an ID up to LastReadMessageID counts as read for that user only.
+type ReadCursor struct {
+	ChannelID         uint      `gorm:"primaryKey;autoIncrement:false" json:"channel_id"`
+	UserID            uint      `gorm:"primaryKey;autoIncrement:false" json:"user_id"`
+	TenantID          uint      `gorm:"index" json:"tenant_id"`
+	LastReadMessageID uint      `json:"last_read_message_id"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+func (ReadCursor) TableName() string {
+	return "read_cursors"
+}
@@ -0,0 +1,18 @@
+package model
+
+import "time"
+
+// RoutingSettings has no column defaults on purpose: false and 0 are valid
+// values, and routing.Settings supplies the defaults for tenants without a row.
+type RoutingSettings struct {
+	TenantID         uint      `gorm:"primaryKey;autoIncrement:false" json:"tenant_id"`
+	Strategy         string    `gorm:"size:50" json:"strategy"`
+	AutoAssign       bool      `json:"auto_assign"`
+	MaxChatsPerAgent int       `json:"max_chats_per_agent"`
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+func (RoutingSettings) TableName() string {
+	return "routing_settings"
+}
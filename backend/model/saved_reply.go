@@ -0,0 +1,20 @@
+package model
+
+import "time"
+
+// SavedReply is a canned response. OwnerID 0 makes it shared across the
+// tenant; otherwise only that agent sees it.
+type SavedReply struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	TenantID  uint      `gorm:"uniqueIndex:idx_saved_reply_shortcut" json:"tenant_id"`
+	OwnerID   uint      `gorm:"uniqueIndex:idx_saved_reply_shortcut" json:"owner_id"`
+	Shortcut  string    `gorm:"size:50;not null;uniqueIndex:idx_saved_reply_shortcut" json:"shortcut"`
+	Title     string    `gorm:"size:150" json:"title"`
+	Body      string    `gorm:"type:text;not null" json:"body"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (SavedReply) TableName() string {
+	return "saved_replies"
+}
@@ -0,0 +1,19 @@
+package model
+
+import "time"
+
+type Session struct {
+	ID         string     `gorm:"primaryKey;size:64" json:"id"`
+	UserID     uint       `gorm:"index;not null" json:"user_id"`
+	Device     string     `json:"device"`
+	IPAddress  string     `gorm:"size:64" json:"ip_address"`
+	UserAgent  string     `gorm:"type:text" json:"user_agent"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt time.Time  `json:"last_used_at"`
+	ExpiresAt  time.Time  `gorm:"index" json:"expires_at"`
+	RevokedAt  *time.Time `gorm:"index" json:"revoked_at"`
+}
+
+func (Session) TableName() string {
+	return "sessions"
+}
@@ -0,0 +1,33 @@
+package model
+
+import "time"
+
+const (
+	SLAFirstResponse = "first_response"
+	SLAResolution    = "resolution"
+)
+
+// SLAPolicy sets response targets for a tenant. A policy with TeamID 0 is the
+// tenant default; a team policy takes precedence for that team's channels.
+// With BusinessHoursOnly the targets only count time inside BusinessStart and
+// BusinessEnd ("15:04") on BusinessDays (comma separated, 0 = Sunday).
+type SLAPolicy struct {
+	ID                   uint      `gorm:"primaryKey" json:"id"`
+	TenantID             uint      `gorm:"index" json:"tenant_id"`
+	TeamID               uint      `gorm:"index" json:"team_id"`
+	Name                 string    `gorm:"size:100;not null" json:"name"`
+	FirstResponseMinutes int       `gorm:"not null" json:"first_response_minutes"`
+	ResolutionMinutes    int       `gorm:"not null" json:"resolution_minutes"`
+	BusinessHoursOnly    bool      `gorm:"default:false" json:"business_hours_only"`
+	Timezone             string    `gorm:"size:64;default:'UTC'" json:"timezone"`
+	BusinessStart        string    `gorm:"size:5;default:'09:00'" json:"business_start"`
+	BusinessEnd          string    `gorm:"size:5;default:'17:00'" json:"business_end"`
+	BusinessDays         string    `gorm:"size:20;default:'1,2,3,4,5'" json:"business_days"`
+	IsActive             bool      `json:"is_active"`
+	CreatedAt            time.Time `json:"created_at"`
+	UpdatedAt            time.Time `json:"updated_at"`
+}
+
+func (SLAPolicy) TableName() string {
+	return "sla_policies"
+}
@@ -0,0 +1,16 @@
+package model
+
+import "time"
+
+type Tag struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	TenantID  uint      `gorm:"uniqueIndex:idx_tenant_tag_name" json:"tenant_id"`
+	Name      string    `gorm:"size:50;not null;uniqueIndex:idx_tenant_tag_name" json:"name"`
+	Color     string    `gorm:"size:20" json:"color"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (Tag) TableName() string {
+	return "tags"
+}
@@ -0,0 +1,49 @@
+package model
+
+import (
+	"slices"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Team groups agents of a tenant. Channels with a TeamID wait in that team's
+// queue; channels without one are in the tenant-wide queue.
+type Team struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	TenantID    uint      `gorm:"uniqueIndex:idx_tenant_team_slug" json:"tenant_id"`
+	Name        string    `gorm:"size:100;not null" json:"name"`
+	Slug        string    `gorm:"size:100;not null;uniqueIndex:idx_tenant_team_slug" json:"slug"`
+	Description string    `gorm:"type:text" json:"description"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+func (Team) TableName() string {
+	return "teams"
+}
+
+type TeamMember struct {
+	TeamID    uint      `gorm:"primaryKey;autoIncrement:false" json:"team_id"`
+	UserID    uint      `gorm:"primaryKey;autoIncrement:false;index" json:"user_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (TeamMember) TableName() string {
+	return "team_members"
+}
+
+// InTeamQueues limits a channel query to the queues an agent in teamIDs may
+// see and claim: the tenant-wide queue plus the queues of their own teams.
+// column is the channel's team_id column, qualified when the query joins.
+func InTeamQueues(query *gorm.DB, column string, teamIDs []uint) *gorm.DB {
+	if len(teamIDs) == 0 {
+		return query.Where(column+" = ?", 0)
+	}
+	return query.Where("("+column+" = ? OR "+column+" IN ?)", 0, teamIDs)
+}
+
+// InTeamQueue is InTeamQueues for a single channel.
+func InTeamQueue(channel Channel, teamIDs []uint) bool {
+	return channel.TeamID == 0 || slices.Contains(teamIDs, channel.TeamID)
+}
@@ -68,17 +68,21 @@ func (r *Role) UnmarshalJSON(data []byte) error {
 }
 
 type User struct {
-	ID           uint           `gorm:"primaryKey" json:"id"`
-	TenantID     uint           `gorm:"index;default:1" json:"tenant_id"`
-	Email        string         `gorm:"unique;not null" json:"email"`
-	PasswordHash string         `gorm:"not null" json:"-"`
-	FullName     string         `json:"full_name"`
-	Phone        string         `json:"phone"`
-	Avatar       string         `json:"avatar"`
-	Role         Role           `gorm:"type:enum('admin','agent','user');default:'user'" json:"role"`
-	IsActive     bool           `gorm:"default:true" json:"is_active"`
-	LastLoginAt  *time.Time     `json:"last_login_at"`
-	CreatedAt    time.Time      `json:"created_at"`
-	UpdatedAt    time.Time      `json:"updated_at"`
-	DeletedAt    gorm.DeletedAt `gorm:"index" json:"-"`
+	ID            uint           `gorm:"primaryKey" json:"id"`
+	TenantID      uint           `gorm:"index;default:1" json:"tenant_id"`
+	Email         string         `gorm:"unique;not null" json:"email"`
+	PasswordHash  string         `gorm:"not null" json:"-"`
+	FullName      string         `json:"full_name"`
+	Phone         string         `json:"phone"`
+	Avatar        string         `json:"avatar"`
+	Role          Role           `gorm:"type:enum('admin','agent','user');default:'user'" json:"role"`
+	IsActive      bool           `gorm:"default:true" json:"is_active"`
+	IsSuperAdmin  bool           `gorm:"default:false" json:"is_super_admin"`
+	Skills        string         `json:"skills"`
+	Availability  string         `gorm:"type:enum('online','busy','away','offline');default:'offline'" json:"availability"`
+	MaxConcurrent int            `gorm:"default:0" json:"max_concurrent"`
+	LastLoginAt   *time.Time     `json:"last_login_at"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+	DeletedAt     gorm.DeletedAt `gorm:"index" json:"-"`
 }
@@ -14,33 +14,129 @@ func SetupRoutes(app *fiber.App) {
 	auth.Post("/register", controller.Register)
 	auth.Post("/login", controller.Login)
 	auth.Post("/logout", controller.Logout)
+	auth.Post("/refresh", controller.RefreshToken)
+	auth.Post("/invitations/accept", controller.AcceptInvitation)
 
 	api.Use(middleware.AllRolesProtected())
 
+	api.Get("/attachments/:id", controller.GetAttachment)
+	api.Post("/presence", controller.PresenceHeartbeat)
+	api.Delete("/presence", controller.PresenceOffline)
+	api.Get("/presence", middleware.AdminOrAgentProtected(), controller.GetPresenceList)
+	api.Get("/presence/events", middleware.AdminOrAgentProtected(), controller.PresenceEvents)
+
+	sessions := api.Group("/sessions")
+	sessions.Get("/", controller.GetMySessions)
+	sessions.Delete("/", controller.RevokeMySessions)
+	sessions.Delete("/:id", controller.RevokeMySession)
+
 	user := api.Group("/user", middleware.UserProtected())
 
 	user.Get("/profile", controller.GetProfile)
-	user.Post("channels", controller.CreateChannel)
+	user.Get("/channels", controller.GetUserConversations)
+	user.Post("/channels", controller.CreateChannel)
+	user.Get("/channels/:id", controller.GetChannelByID)
 	user.Post("/channels/:id/messages", controller.SendMessage)
+	user.Get("/channels/:id/events", controller.ChannelEvents)
+	user.Post("/channels/:id/read", controller.MarkChannelRead)
+	user.Post("/channels/:id/typing", controller.SendTyping)
 
 	agent := api.Group("/agent", middleware.AgentProtected())
 
 	agent.Get("/conversations", controller.GetAgentConversations)
+	agent.Get("/teams", controller.GetMyTeams)
+	agent.Get("/availability", controller.GetMyAvailability)
+	agent.Patch("/availability", controller.UpdateMyAvailability)
 	agent.Get("/channels/available", controller.GetAvailableChannels)
 	agent.Get("/channels/stats", controller.GetChannelStats)
 	agent.Get("/channels/:id", controller.GetChannelByID)
+	agent.Get("/events", controller.AgentEvents)
 
 	agent.Patch("/channels/:id/assign", controller.AssignChannel)
+	agent.Patch("/channels/:id/transfer", controller.TransferChannel)
 	agent.Post("/channels/:id/close", controller.CloseChannel)
+	agent.Patch("/channels/:id/status", controller.UpdateChannelStatus)
 	agent.Post("/channels/:id/messages", controller.SendMessage)
+	agent.Post("/channels/:id/read", controller.MarkChannelRead)
+	agent.Post("/channels/:id/typing", controller.SendTyping)
+	agent.Get("/replies", controller.GetSavedReplies)
+	agent.Get("/replies/search", controller.SearchSavedReplies)
+	agent.Post("/replies", controller.CreateSavedReply)
+	agent.Put("/replies/:id", controller.UpdateSavedReply)
+	agent.Delete("/replies/:id", controller.DeleteSavedReply)
+	agent.Get("/tags", controller.GetTags)
+	agent.Post("/channels/:id/tags", controller.AddChannelTag)
+	agent.Delete("/channels/:id/tags/:tagId", controller.RemoveChannelTag)
+	agent.Get("/channels/:id/notes", controller.GetNotes)
+	agent.Post("/channels/:id/notes", controller.CreateNote)
 
 	admin := api.Group("/admin", middleware.AdminProtected())
 
 	admin.Get("/users", controller.GetAllUsers)
+	admin.Post("/users", controller.CreateUser)
+	admin.Put("/users/:id", controller.UpdateUser)
+	admin.Delete("/users/:id", controller.DeleteUser)
+	admin.Post("/users/:id/restore", controller.RestoreUser)
+	admin.Post("/users/:id/activate", controller.ActivateUser)
+	admin.Post("/users/:id/deactivate", controller.DeactivateUser)
+	admin.Patch("/users/:id/role", controller.ChangeUserRole)
+	admin.Post("/users/:id/reset-password", controller.ResetUserPassword)
+	admin.Get("/invitations", controller.GetInvitations)
+	admin.Post("/invitations", controller.CreateInvitation)
+	admin.Get("/users/:id/sessions", controller.GetUserSessions)
+	admin.Delete("/users/:id/sessions", controller.RevokeUserSessions)
+	admin.Delete("/users/:id/sessions/:sessionId", controller.RevokeUserSession)
+	admin.Get("/sla-policies", controller.GetSLAPolicies)
+	admin.Post("/sla-policies", controller.CreateSLAPolicy)
+	admin.Put("/sla-policies/:id", controller.UpdateSLAPolicy)
+	admin.Delete("/sla-policies/:id", controller.DeleteSLAPolicy)
+	admin.Get("/teams", controller.GetTeams)
+	admin.Post("/teams", controller.CreateTeam)
+	admin.Get("/teams/stats", controller.GetTeamStats)
+	admin.Put("/teams/:id", controller.UpdateTeam)
+	admin.Delete("/teams/:id", controller.DeleteTeam)
+	admin.Get("/teams/:id/members", controller.GetTeamMembers)
+	admin.Post("/teams/:id/members", controller.AddTeamMember)
+	admin.Delete("/teams/:id/members/:userId", controller.RemoveTeamMember)
+	admin.Get("/agents/availability", controller.GetAgentAvailability)
+	admin.Patch("/agents/:id/capacity", controller.UpdateAgentCapacity)
+	admin.Get("/routing", controller.GetRoutingSettings)
+	admin.Put("/routing", controller.UpdateRoutingSettings)
 	admin.Get("/channels/available", controller.GetAvailableChannels)
 	admin.Patch("/channels/:id/assign", controller.AssignChannel)
+	admin.Patch("/channels/:id/reassign", controller.ReassignChannel)
+	admin.Patch("/channels/:id/transfer", controller.TransferChannel)
+	admin.Patch("/channels/:id/status", controller.UpdateChannelStatus)
+	admin.Get("/replies", controller.GetSavedReplies)
+	admin.Get("/replies/search", controller.SearchSavedReplies)
+	admin.Post("/replies", controller.CreateSavedReply)
+	admin.Put("/replies/:id", controller.UpdateSavedReply)
+	admin.Delete("/replies/:id", controller.DeleteSavedReply)
+	admin.Get("/tags", controller.GetTags)
+	admin.Post("/tags", controller.CreateTag)
+	admin.Put("/tags/:id", controller.UpdateTag)
+	admin.Delete("/tags/:id", controller.DeleteTag)
+	admin.Post("/channels/:id/tags", controller.AddChannelTag)
+	admin.Delete("/channels/:id/tags/:tagId", controller.RemoveChannelTag)
+	admin.Post("/channels/:id/read", controller.MarkChannelRead)
+	admin.Get("/channels/:id/notes", controller.GetNotes)
+	admin.Post("/channels/:id/notes", controller.CreateNote)
+
+	superAdmin := api.Group("/superadmin", middleware.AdminProtected(), middleware.SuperAdminProtected())
+
+	superAdmin.Get("/tenants", controller.GetTenants)
+	superAdmin.Post("/tenants", controller.CreateTenant)
+	superAdmin.Get("/tenants/:id", controller.GetTenantByID)
+	superAdmin.Put("/tenants/:id", controller.UpdateTenant)
+	superAdmin.Delete("/tenants/:id", controller.DeleteTenant)
 
 	adminOrAgent := api.Group("/conversations", middleware.AdminOrAgentProtected())
+	adminOrAgent.Get("/search", controller.SearchConversations)
 	adminOrAgent.Get("/:id", controller.GetChannelByID)
+	adminOrAgent.Get("/:id/events", controller.ChannelEvents)
+	adminOrAgent.Get("/:id/timeline", controller.GetChannelTimeline)
+
+	ws := api.Group("/ws")
+	ws.Get("/channels/:id", controller.ChannelWebSocket)
 
 }
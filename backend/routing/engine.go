@@ -0,0 +1,203 @@
+package routing
+
+import (
+	"backend/config"
+	"backend/model"
+	"errors"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// activeStatuses count towards an agent's capacity.
+var activeStatuses = []string{model.ChannelAssigned, model.ChannelPending, model.ChannelReopened}
+
+var (
+	ErrNoAgentAvailable = errors.New("no agent available")
+	ErrAlreadyAssigned  = errors.New("channel is no longer open")
+	ErrAtCapacity       = errors.New("agent is at capacity")
+)
+
+const (
+	DefaultStrategy         = "least_open"
+	DefaultMaxChatsPerAgent = 5
+
+	// An agent counts as online while one of their sessions was used recently.
+	onlineWindow = 5 * time.Minute
+)
+
+func Settings(db *gorm.DB, tenantID uint) model.RoutingSettings {
+	settings := model.RoutingSettings{
+		TenantID:         tenantID,
+		Strategy:         DefaultStrategy,
+		AutoAssign:       true,
+		MaxChatsPerAgent: DefaultMaxChatsPerAgent,
+	}
+	db.Where("tenant_id = ?", tenantID).Limit(1).Find(&settings)
+	return settings
+}
+
+// Route assigns an open, unassigned channel to an agent using the tenant's
+// strategy. It returns ErrNoAgentAvailable when the channel should stay queued.
+func Route(db *gorm.DB, channel model.Channel) (uint, error) {
+	settings := Settings(db, channel.TenantID)
+	if !settings.AutoAssign {
+		return 0, ErrNoAgentAvailable
+	}
+
+	strategy, ok := Lookup(settings.Strategy)
+	if !ok {
+		strategy, _ = Lookup(DefaultStrategy)
+	}
+
+	candidates, err := candidatesFor(db, channel, settings.MaxChatsPerAgent)
+	if err != nil {
+		return 0, err
+	}
+
+	picked := strategy.Pick(channel, candidates)
+	if picked == nil {
+		return 0, ErrNoAgentAvailable
+	}
+
+	if _, _, err := Claim(db, channel.ID, picked.Agent.ID, true); err != nil {
+		if errors.Is(err, ErrAtCapacity) {
+			return 0, ErrNoAgentAvailable
+		}
+		return 0, err
+	}
+
+	return picked.Agent.ID, nil
+}
+
+// Claim assigns a queued, unassigned channel to an agent. With
+// enforceCapacity the agent's row is locked while their active chats are
+// counted, so concurrent claims for one agent cannot exceed the limit. The
+// returned limit and count are only set when the capacity was checked.
+func Claim(db *gorm.DB, channelID uint, agentID uint, enforceCapacity bool) (limit int, open int64, err error) {
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if enforceCapacity {
+			var agent model.User
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&agent, agentID).Error; err != nil {
+				return err
+			}
+			limit, open = Capacity(tx, agent)
+			if limit > 0 && open >= int64(limit) {
+				return ErrAtCapacity
+			}
+		}
+
+		result := tx.Model(&model.Channel{}).
+			Where("id = ? AND status IN ? AND assigned_agent_id = ?", channelID, model.ClaimableStatuses, 0).
+			Updates(map[string]interface{}{
+				"assigned_agent_id": agentID,
+				"status":            model.ChannelAssigned,
+				"updated_at":        time.Now(),
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrAlreadyAssigned
+		}
+		return nil
+	})
+	return limit, open, err
+}
+
+// Capacity returns how many active chats the agent may hold and how many they
+// hold now. A limit of 0 means unlimited.
+func Capacity(db *gorm.DB, agent model.User) (limit int, open int64) {
+	limit = agent.MaxConcurrent
+	if limit == 0 {
+		limit = Settings(db, agent.TenantID).MaxChatsPerAgent
+	}
+
+	db.Model(&model.Channel{}).
+		Where("assigned_agent_id = ? AND status IN ?", agent.ID, activeStatuses).
+		Count(&open)
+
+	return limit, open
+}
+
+// candidatesFor lists online agents with spare capacity. A channel aimed at a
+// team only goes to members of that team.
+func candidatesFor(db *gorm.DB, channel model.Channel, maxChats int) ([]Candidate, error) {
+	query := db.Model(&model.User{}).
+		Where("tenant_id = ? AND role = ? AND is_active = ?", channel.TenantID, model.RoleAgent, true).
+		Where("availability = ?", model.AvailabilityOnline).
+		Where("id IN (?)", db.Model(&model.Session{}).
+			Select("user_id").
+			Where("revoked_at IS NULL AND last_used_at > ?", time.Now().Add(-onlineWindow)))
+	if channel.TeamID != 0 {
+		query = query.Where("id IN (?)", db.Model(&model.TeamMember{}).
+			Select("user_id").
+			Where("team_id = ?", channel.TeamID))
+	}
+
+	var agents []model.User
+	err := query.Order("id ASC").Find(&agents).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var candidates []Candidate
+	for _, agent := range agents {
+		var open int64
+		db.Model(&model.Channel{}).
+			Where("assigned_agent_id = ? AND status IN ?", agent.ID, activeStatuses).
+			Count(&open)
+
+		limit := maxChats
+		if agent.MaxConcurrent > 0 {
+			limit = agent.MaxConcurrent
+		}
+		if limit > 0 && open >= int64(limit) {
+			continue
+		}
+		candidates = append(candidates, Candidate{Agent: agent, OpenCount: open})
+	}
+
+	return candidates, nil
+}
+
+// StartWorker periodically retries routing for queued channels. A short Redis
+// lock keeps several backend instances from routing the same queue at once.
+func StartWorker(db *gorm.DB, interval time.Duration, onAssigned func(channel model.Channel, agentID uint)) {
+	ticker := time.NewTicker(interval)
+	go func() {
+		for range ticker.C {
+			ok, err := config.RedisClient.SetNX(config.Ctx, "routing:lock", 1, interval).Result()
+			if err != nil || !ok {
+				continue
+			}
+			drainQueue(db, onAssigned)
+		}
+	}()
+}
+
+func drainQueue(db *gorm.DB, onAssigned func(channel model.Channel, agentID uint)) {
+	var queued []model.Channel
+	if err := db.Where("status IN ? AND assigned_agent_id = ?", model.ClaimableStatuses, 0).
+		Order("id ASC").
+		Limit(200).
+		Find(&queued).Error; err != nil {
+		log.Printf("routing: %v", err)
+		return
+	}
+
+	for _, channel := range queued {
+		agentID, err := Route(db, channel)
+		if err != nil {
+			if !errors.Is(err, ErrNoAgentAvailable) && !errors.Is(err, ErrAlreadyAssigned) {
+				log.Printf("routing: channel %d: %v", channel.ID, err)
+			}
+			continue
+		}
+		if onAssigned != nil {
+			onAssigned(channel, agentID)
+		}
+	}
+}
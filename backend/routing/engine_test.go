@@ -0,0 +1,136 @@
+package routing
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func mockDB(t *testing.T) (*gorm.DB, sqlmock.Sqlmock) {
+	t.Helper()
+	sqlDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+
+	db, err := gorm.Open(mysql.New(mysql.Config{Conn: sqlDB, SkipInitializeWithVersion: true}),
+		&gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db, mock
+}
+
+// claimUpdate matches the guarded claim: it only touches a channel that is
+// still queued and has no agent.
+const claimUpdate = "UPDATE `channels` SET .* WHERE id = \\? AND status IN \\(\\?,\\?\\) AND assigned_agent_id = \\?"
+
+func TestClaimAssignsQueuedChannel(t *testing.T) {
+	db, mock := mockDB(t)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(claimUpdate).
+		WithArgs(uint(3), "assigned", sqlmock.AnyArg(), uint(10), "open", "reopened", 0).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	if _, _, err := Claim(db, 10, 3, false); err != nil {
+		t.Fatalf("Claim: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestClaimLosesRace(t *testing.T) {
+	db, mock := mockDB(t)
+
+	// Another agent claimed the channel first, so the guard matches no row.
+	mock.ExpectBegin()
+	mock.ExpectExec(claimUpdate).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	if _, _, err := Claim(db, 10, 3, false); !errors.Is(err, ErrAlreadyAssigned) {
+		t.Fatalf("err = %v, want ErrAlreadyAssigned", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestClaimDatabaseError(t *testing.T) {
+	db, mock := mockDB(t)
+
+	failure := errors.New("connection reset")
+	mock.ExpectBegin()
+	mock.ExpectExec(claimUpdate).WillReturnError(failure)
+	mock.ExpectRollback()
+
+	if _, _, err := Claim(db, 10, 3, false); !errors.Is(err, failure) {
+		t.Fatalf("err = %v, want %v", err, failure)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestClaimEnforcesCapacity(t *testing.T) {
+	tests := []struct {
+		name          string
+		maxConcurrent int
+		tenantMax     *int // nil when the agent's own limit applies
+		open          int64
+		wantLimit     int
+		wantErr       error
+	}{
+		{name: "under own limit", maxConcurrent: 2, open: 1, wantLimit: 2},
+		{name: "at own limit", maxConcurrent: 2, open: 2, wantLimit: 2, wantErr: ErrAtCapacity},
+		{name: "at tenant limit", tenantMax: intPtr(3), open: 3, wantLimit: 3, wantErr: ErrAtCapacity},
+		{name: "tenant limit of zero is unlimited", tenantMax: intPtr(0), open: 40, wantLimit: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := mockDB(t)
+
+			mock.ExpectBegin()
+			// The agent row stays locked until the claim commits, so a
+			// concurrent claim for the same agent waits for this count.
+			mock.ExpectQuery("SELECT \\* FROM `users` WHERE `users`.`id` = \\? .*FOR UPDATE").
+				WithArgs(uint(3), 1).
+				WillReturnRows(sqlmock.NewRows([]string{"id", "tenant_id", "max_concurrent"}).
+					AddRow(3, 1, tt.maxConcurrent))
+			if tt.tenantMax != nil {
+				mock.ExpectQuery("SELECT \\* FROM `routing_settings` WHERE tenant_id = \\?").
+					WillReturnRows(sqlmock.NewRows([]string{"tenant_id", "max_chats_per_agent"}).
+						AddRow(1, *tt.tenantMax))
+			}
+			mock.ExpectQuery("SELECT count\\(\\*\\) FROM `channels` WHERE assigned_agent_id = \\? AND status IN").
+				WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(tt.open))
+			if tt.wantErr == nil {
+				mock.ExpectExec(claimUpdate).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			} else {
+				mock.ExpectRollback()
+			}
+
+			limit, open, err := Claim(db, 10, 3, true)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if limit != tt.wantLimit || open != tt.open {
+				t.Errorf("capacity = %d/%d, want %d/%d", open, limit, tt.open, tt.wantLimit)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func intPtr(v int) *int { return &v }
@@ -0,0 +1,112 @@
+package routing
+
+import (
+	"backend/config"
+	"backend/model"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Candidate is an online agent of the channel's tenant with spare capacity.
+type Candidate struct {
+	Agent     model.User
+	OpenCount int64
+}
+
+// Strategy picks the agent for a channel, or returns nil to leave it queued.
+// Candidates are sorted by agent ID.
+type Strategy interface {
+	Name() string
+	Pick(channel model.Channel, candidates []Candidate) *Candidate
+}
+
+var strategies = map[string]Strategy{}
+
+func Register(strategy Strategy) {
+	strategies[strategy.Name()] = strategy
+}
+
+func Lookup(name string) (Strategy, bool) {
+	strategy, ok := strategies[name]
+	return strategy, ok
+}
+
+func StrategyNames() []string {
+	names := make([]string, 0, len(strategies))
+	for name := range strategies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func init() {
+	Register(RoundRobin{})
+	Register(LeastOpen{})
+	Register(SkillBased{})
+}
+
+// RoundRobin keeps its cursor in Redis so every instance shares the rotation.
+type RoundRobin struct{}
+
+func (RoundRobin) Name() string { return "round_robin" }
+
+func (RoundRobin) Pick(channel model.Channel, candidates []Candidate) *Candidate {
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	key := fmt.Sprintf("routing:rr:%d", channel.TenantID)
+	next, err := config.RedisClient.Incr(config.Ctx, key).Result()
+	if err != nil {
+		next = 1
+	}
+
+	return &candidates[int(next-1)%len(candidates)]
+}
+
+type LeastOpen struct{}
+
+func (LeastOpen) Name() string { return "least_open" }
+
+func (LeastOpen) Pick(channel model.Channel, candidates []Candidate) *Candidate {
+	var best *Candidate
+	for i := range candidates {
+		if best == nil || candidates[i].OpenCount < best.OpenCount {
+			best = &candidates[i]
+		}
+	}
+	return best
+}
+
+// SkillBased only considers agents whose skills include the channel's skill,
+// then falls back to the least busy of them. Channels without a skill can go
+// to anyone.
+type SkillBased struct{}
+
+func (SkillBased) Name() string { return "skill_based" }
+
+func (SkillBased) Pick(channel model.Channel, candidates []Candidate) *Candidate {
+	if channel.Skill == "" {
+		return LeastOpen{}.Pick(channel, candidates)
+	}
+
+	var skilled []Candidate
+	for _, candidate := range candidates {
+		if hasSkill(candidate.Agent.Skills, channel.Skill) {
+			skilled = append(skilled, candidate)
+		}
+	}
+
+	return LeastOpen{}.Pick(channel, skilled)
+}
+
+func hasSkill(skills string, skill string) bool {
+	for _, s := range strings.Split(skills, ",") {
+		if strings.EqualFold(strings.TrimSpace(s), skill) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,101 @@
+package routing
+
+import (
+	"backend/config"
+	"backend/model"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func candidate(id uint, open int64, skills string) Candidate {
+	return Candidate{Agent: model.User{ID: id, Skills: skills}, OpenCount: open}
+}
+
+func pickedID(c *Candidate) uint {
+	if c == nil {
+		return 0
+	}
+	return c.Agent.ID
+}
+
+func TestLeastOpen(t *testing.T) {
+	tests := []struct {
+		name       string
+		candidates []Candidate
+		want       uint
+	}{
+		{"no candidates", nil, 0},
+		{"fewest open chats", []Candidate{candidate(1, 3, ""), candidate(2, 1, ""), candidate(3, 2, "")}, 2},
+		{"tie goes to lowest ID", []Candidate{candidate(1, 1, ""), candidate(2, 1, "")}, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := pickedID(LeastOpen{}.Pick(model.Channel{}, tt.candidates)); got != tt.want {
+				t.Errorf("picked %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSkillBased(t *testing.T) {
+	candidates := []Candidate{
+		candidate(1, 0, "sales"),
+		candidate(2, 2, "billing, Support"),
+		candidate(3, 1, "support"),
+	}
+
+	tests := []struct {
+		name  string
+		skill string
+		want  uint
+	}{
+		{"no skill falls back to least open", "", 1},
+		{"least open among skilled", "support", 3},
+		{"skills match case-insensitively", "BILLING", 2},
+		{"nobody has the skill", "legal", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := pickedID(SkillBased{}.Pick(model.Channel{Skill: tt.skill}, candidates))
+			if got != tt.want {
+				t.Errorf("picked %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRoundRobinRotatesPerTenant(t *testing.T) {
+	server := miniredis.RunT(t)
+	previous := config.RedisClient
+	config.RedisClient = redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() {
+		config.RedisClient.Close()
+		config.RedisClient = previous
+	})
+
+	candidates := []Candidate{candidate(1, 0, ""), candidate(2, 0, ""), candidate(3, 0, "")}
+	tenantA := model.Channel{TenantID: 1}
+	tenantB := model.Channel{TenantID: 2}
+
+	var got []uint
+	for i := 0; i < 4; i++ {
+		got = append(got, pickedID(RoundRobin{}.Pick(tenantA, candidates)))
+	}
+	want := []uint{1, 2, 3, 1}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("tenant A picks = %v, want %v", got, want)
+		}
+	}
+
+	if id := pickedID(RoundRobin{}.Pick(tenantB, candidates)); id != 1 {
+		t.Errorf("tenant B first pick = %d, want 1: rotations must not be shared", id)
+	}
+	if id := pickedID(RoundRobin{}.Pick(tenantA, nil)); id != 0 {
+		t.Errorf("pick without candidates = %d, want none", id)
+	}
+}
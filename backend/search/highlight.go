@@ -0,0 +1,72 @@
+package search
+
+import (
+	"html"
+	"strings"
+	"unicode/utf8"
+)
+
+const snippetRadius = 60
+
+// Highlight cuts a window of text around the first matching term and wraps
+// every term occurrence in <mark>. The text is HTML-escaped first.
+func Highlight(text string, terms []string) string {
+	lower := strings.ToLower(text)
+	if len(lower) != len(text) {
+		// Offsets below index both strings; fall back to exact-case matching
+		// for the rare runes whose lower case has a different width.
+		lower = text
+	}
+
+	start, end := 0, len(text)
+	first := -1
+	for _, term := range terms {
+		if i := strings.Index(lower, term); i >= 0 && (first < 0 || i < first) {
+			first = i
+		}
+	}
+	if first >= 0 {
+		start = max(first-snippetRadius, 0)
+		end = min(first+snippetRadius*2, len(text))
+	} else {
+		end = min(snippetRadius*3, len(text))
+	}
+
+	// Keep the window on rune boundaries.
+	for start > 0 && !utf8.RuneStart(text[start]) {
+		start--
+	}
+	for end < len(text) && !utf8.RuneStart(text[end]) {
+		end++
+	}
+
+	window := text[start:end]
+	lowerWindow := lower[start:end]
+
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("…")
+	}
+	for i := 0; i < len(window); {
+		matched := ""
+		for _, term := range terms {
+			if term != "" && strings.HasPrefix(lowerWindow[i:], term) && len(term) > len(matched) {
+				matched = term
+			}
+		}
+		if matched != "" {
+			b.WriteString("<mark>")
+			b.WriteString(html.EscapeString(window[i : i+len(matched)]))
+			b.WriteString("</mark>")
+			i += len(matched)
+			continue
+		}
+		_, size := utf8.DecodeRuneInString(window[i:])
+		b.WriteString(html.EscapeString(window[i : i+size]))
+		i += size
+	}
+	if end < len(text) {
+		b.WriteString("…")
+	}
+	return b.String()
+}
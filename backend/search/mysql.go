@@ -0,0 +1,130 @@
+package search
+
+import (
+	"backend/model"
+	"context"
+	"strings"
+	"unicode"
+
+	"gorm.io/gorm"
+)
+
+// MySQL searches message text through the FULLTEXT index on messages.message
+// and customer name and email with LIKE.
+type MySQL struct {
+	db *gorm.DB
+}
+
+func NewMySQL(db *gorm.DB) *MySQL {
+	return &MySQL{db: db}
+}
+
+func (m *MySQL) Search(ctx context.Context, q Query) (Result, error) {
+	terms := Terms(q.Text)
+	if len(terms) == 0 {
+		return Result{Hits: []Hit{}}, nil
+	}
+
+	db := m.db.WithContext(ctx)
+	against := booleanQuery(terms)
+	like := "%" + strings.Join(terms, " ") + "%"
+
+	base := db.Table("channels").
+		Joins("LEFT JOIN users customers ON customers.id = channels.customer_id")
+	if against != "" {
+		base = base.Where("(customers.full_name LIKE ? OR customers.email LIKE ? OR channels.id IN (?))",
+			like, like,
+			db.Table("messages").Select("conversation_id").
+				Where("MATCH(message) AGAINST (? IN BOOLEAN MODE)", against))
+	} else {
+		base = base.Where("(customers.full_name LIKE ? OR customers.email LIKE ?)", like, like)
+	}
+
+	if !q.AllTenants {
+		base = base.Where("channels.tenant_id = ?", q.TenantID)
+	}
+	if q.AgentID != 0 {
+		if q.IncludeQueue {
+			queue := model.InTeamQueues(db.Where("channels.assigned_agent_id = ?", 0), "channels.team_id", q.QueueTeamIDs)
+			base = base.Where(db.Where("channels.assigned_agent_id = ?", q.AgentID).Or(queue))
+		} else {
+			base = base.Where("channels.assigned_agent_id = ?", q.AgentID)
+		}
+	}
+	if len(q.Statuses) > 0 {
+		base = base.Where("channels.status IN ?", q.Statuses)
+	}
+	if q.From != nil {
+		base = base.Where("channels.created_at >= ?", *q.From)
+	}
+	if q.To != nil {
+		base = base.Where("channels.created_at < ?", *q.To)
+	}
+	if len(q.Tags) > 0 {
+		base = base.Where("channels.id IN (?)", db.Table("channel_tags").
+			Select("channel_tags.channel_id").
+			Joins("JOIN tags ON tags.id = channel_tags.tag_id").
+			Where("tags.name IN ?", q.Tags))
+	}
+
+	// Count and the page query both start from the same conditions.
+	base = base.Session(&gorm.Session{})
+
+	var total int64
+	if err := base.Count(&total).Error; err != nil {
+		return Result{}, err
+	}
+
+	hits := []Hit{}
+	err := base.Select("channels.id AS channel_id, channels.tenant_id, channels.status, " +
+		"channels.assigned_agent_id, channels.customer_id, customers.full_name AS customer_name, " +
+		"customers.email AS customer_email, channels.created_at, channels.updated_at").
+		Order("channels.updated_at DESC").
+		Limit(q.Limit).
+		Offset(q.Offset).
+		Scan(&hits).Error
+	if err != nil {
+		return Result{}, err
+	}
+
+	for i := range hits {
+		var message model.Message
+		if against != "" {
+			db.Where("conversation_id = ? AND MATCH(message) AGAINST (? IN BOOLEAN MODE)", hits[i].ChannelID, against).
+				Order("id DESC").
+				Limit(1).
+				Find(&message)
+		}
+
+		if message.ID != 0 {
+			hits[i].MessageID = message.ID
+			hits[i].Snippet = Highlight(message.Message, terms)
+		} else {
+			hits[i].Snippet = Highlight(hits[i].CustomerName+" <"+hits[i].CustomerEmail+">", terms)
+		}
+	}
+
+	return Result{Hits: hits, Total: total}, nil
+}
+
+// booleanQuery requires every term and matches word prefixes, so "refu inv"
+// finds "refund invoice". Terms kept for the LIKE match, such as email
+// addresses, are split at '@' (the proximity operator in boolean mode) and
+// '.', which the FULLTEXT parser treats as word breaks anyway.
+func booleanQuery(terms []string) string {
+	parts := []string{}
+	for _, term := range terms {
+		for _, word := range strings.FieldsFunc(term, func(r rune) bool { return r == '@' || r == '.' }) {
+			parts = append(parts, "+"+word+"*")
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// Terms splits free text into lower-case words, dropping anything that has
+// meaning in MySQL boolean mode.
+func Terms(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r) && r != '@' && r != '.' && r != '_'
+	})
+}
@@ -0,0 +1,60 @@
+package search
+
+import (
+	"context"
+	"time"
+)
+
+// Query describes a conversation search. TenantID is always applied unless
+// AllTenants is set; AgentID restricts results to that agent's channels.
+type Query struct {
+	Text         string
+	TenantID     uint
+	AllTenants   bool
+	AgentID      uint
+	IncludeQueue bool
+	// QueueTeamIDs limits IncludeQueue to the tenant-wide queue plus the
+	// queues of these teams.
+	QueueTeamIDs []uint
+	Statuses     []string
+	Tags         []string
+	From         *time.Time
+	To           *time.Time
+	Limit        int
+	Offset       int
+}
+
+type Hit struct {
+	ChannelID       uint      `json:"channel_id"`
+	TenantID        uint      `json:"tenant_id"`
+	Status          string    `json:"status"`
+	AssignedAgentID uint      `json:"assigned_agent_id"`
+	CustomerID      uint      `json:"customer_id"`
+	CustomerName    string    `json:"customer_name"`
+	CustomerEmail   string    `json:"customer_email"`
+	MessageID       uint      `json:"message_id,omitempty"`
+	Snippet         string    `json:"snippet"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+type Result struct {
+	Hits  []Hit `json:"hits"`
+	Total int64 `json:"total"`
+}
+
+// Indexer answers conversation searches. The default implementation queries
+// MySQL directly; an external index can be plugged in with SetIndexer.
+type Indexer interface {
+	Search(ctx context.Context, q Query) (Result, error)
+}
+
+var current Indexer
+
+func SetIndexer(indexer Indexer) {
+	current = indexer
+}
+
+func Default() Indexer {
+	return current
+}
@@ -0,0 +1,107 @@
+package sla
+
+import (
+	"backend/model"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule counts SLA time. Without business hours every minute counts;
+// otherwise only minutes between Start and End on the listed days do.
+type Schedule struct {
+	BusinessHoursOnly bool
+	Location          *time.Location
+	Start             time.Duration
+	End               time.Duration
+	Days              map[time.Weekday]bool
+}
+
+// ScheduleFor builds the schedule of a policy. Malformed hours or timezones
+// fall back to 09:00-17:00 UTC so a bad setting never stops the clock.
+func ScheduleFor(policy model.SLAPolicy) Schedule {
+	schedule := Schedule{
+		BusinessHoursOnly: policy.BusinessHoursOnly,
+		Location:          time.UTC,
+		Start:             9 * time.Hour,
+		End:               17 * time.Hour,
+		Days:              map[time.Weekday]bool{},
+	}
+
+	if loc, err := time.LoadLocation(policy.Timezone); err == nil && policy.Timezone != "" {
+		schedule.Location = loc
+	}
+
+	start, okStart := parseClock(policy.BusinessStart)
+	end, okEnd := parseClock(policy.BusinessEnd)
+	if okStart && okEnd && start < end {
+		schedule.Start, schedule.End = start, end
+	}
+
+	for _, part := range strings.Split(policy.BusinessDays, ",") {
+		day, err := strconv.Atoi(strings.TrimSpace(part))
+		if err == nil && day >= 0 && day <= 6 {
+			schedule.Days[time.Weekday(day)] = true
+		}
+	}
+
+	return schedule
+}
+
+// ValidClock reports whether s is a "15:04" time of day.
+func ValidClock(s string) bool {
+	_, ok := parseClock(s)
+	return ok
+}
+
+func parseClock(s string) (time.Duration, bool) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, false
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, true
+}
+
+// Add returns the moment d of counted time has passed since from.
+func (s Schedule) Add(from time.Time, d time.Duration) time.Time {
+	if !s.BusinessHoursOnly || len(s.Days) == 0 || s.End <= s.Start {
+		return from.Add(d)
+	}
+
+	t := from.In(s.Location)
+	remaining := d
+
+	// One iteration per calendar day; a year without a working day would
+	// mean the schedule is unusable, so give up and count wall-clock time.
+	for i := 0; i < 366*2; i++ {
+		dayStart := s.clock(t, s.Start)
+		dayEnd := s.clock(t, s.End)
+		nextDay := time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, s.Location)
+
+		if !s.Days[t.Weekday()] || !t.Before(dayEnd) {
+			t = nextDay
+			continue
+		}
+		if t.Before(dayStart) {
+			t = dayStart
+		}
+
+		available := dayEnd.Sub(t)
+		if remaining <= available {
+			return t.Add(remaining)
+		}
+		remaining -= available
+		t = nextDay
+	}
+
+	return from.Add(d)
+}
+
+// clock returns the wall-clock time offset after midnight on day's date.
+// Building it from the date keeps business hours right on DST change days,
+// where midnight plus a duration would be an hour off.
+func (s Schedule) clock(day time.Time, offset time.Duration) time.Time {
+	hour := int(offset / time.Hour)
+	minute := int(offset % time.Hour / time.Minute)
+	return time.Date(day.Year(), day.Month(), day.Day(), hour, minute, 0, 0, s.Location)
+}
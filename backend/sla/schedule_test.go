@@ -0,0 +1,130 @@
+package sla
+
+import (
+	"backend/model"
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+func weekdays(days ...time.Weekday) map[time.Weekday]bool {
+	set := map[time.Weekday]bool{}
+	for _, day := range days {
+		set[day] = true
+	}
+	return set
+}
+
+func TestScheduleAdd(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	officeHours := Schedule{
+		BusinessHoursOnly: true,
+		Location:          time.UTC,
+		Start:             9 * time.Hour,
+		End:               17 * time.Hour,
+		Days:              weekdays(time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday),
+	}
+	mondayWednesday := officeHours
+	mondayWednesday.Days = weekdays(time.Monday, time.Wednesday)
+	everyDayNewYork := officeHours
+	everyDayNewYork.Location = newYork
+	everyDayNewYork.Days = weekdays(time.Sunday, time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday, time.Saturday)
+	wallClock := officeHours
+	wallClock.BusinessHoursOnly = false
+
+	utc := func(month time.Month, day, hour, minute int) time.Time {
+		return time.Date(2026, month, day, hour, minute, 0, 0, time.UTC)
+	}
+	ny := func(month time.Month, day, hour, minute int) time.Time {
+		return time.Date(2026, month, day, hour, minute, 0, 0, newYork)
+	}
+
+	tests := []struct {
+		name     string
+		schedule Schedule
+		from     time.Time
+		d        time.Duration
+		want     time.Time
+	}{
+		// 2026-10-12 is a Monday.
+		{"wall clock", wallClock, utc(10, 10, 22, 0), 3 * time.Hour, utc(10, 11, 1, 0)},
+		{"within hours", officeHours, utc(10, 12, 10, 0), 2 * time.Hour, utc(10, 12, 12, 0)},
+		{"before hours", officeHours, utc(10, 12, 7, 0), time.Hour, utc(10, 12, 10, 0)},
+		{"after hours", officeHours, utc(10, 12, 18, 0), time.Hour, utc(10, 13, 10, 0)},
+		{"at closing time", officeHours, utc(10, 12, 17, 0), time.Minute, utc(10, 13, 9, 1)},
+		{"ends exactly at closing", officeHours, utc(10, 12, 9, 0), 8 * time.Hour, utc(10, 12, 17, 0)},
+		{"carries into next day", officeHours, utc(10, 12, 16, 0), 2 * time.Hour, utc(10, 13, 10, 0)},
+		{"spans several days", officeHours, utc(10, 12, 9, 0), 20 * time.Hour, utc(10, 14, 13, 0)},
+		{"carries across weekend", officeHours, utc(10, 16, 16, 30), time.Hour, utc(10, 19, 9, 30)},
+		{"starts on weekend", officeHours, utc(10, 17, 12, 0), 30 * time.Minute, utc(10, 19, 9, 30)},
+		{"skips unlisted day", mondayWednesday, utc(10, 12, 16, 0), 2 * time.Hour, utc(10, 14, 10, 0)},
+		{"starts on unlisted day", mondayWednesday, utc(10, 13, 10, 0), time.Hour, utc(10, 14, 10, 0)},
+		// America/New_York springs forward on 2026-03-08 and falls back on 2026-11-01.
+		{"spring forward day", everyDayNewYork, ny(3, 8, 0, 30), time.Hour, ny(3, 8, 10, 0)},
+		{"fall back day", everyDayNewYork, ny(11, 1, 0, 30), time.Hour, ny(11, 1, 10, 0)},
+		{"carries into spring forward", everyDayNewYork, ny(3, 7, 16, 0), 2 * time.Hour, ny(3, 8, 10, 0)},
+		{"carries into fall back", everyDayNewYork, ny(10, 31, 16, 0), 2 * time.Hour, ny(11, 1, 10, 0)},
+		{"from another zone", everyDayNewYork, utc(3, 9, 12, 0), time.Hour, ny(3, 9, 10, 0)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.schedule.Add(tt.from, tt.d)
+			if !got.Equal(tt.want) {
+				t.Errorf("Add(%s, %s) = %s, want %s", tt.from, tt.d, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScheduleFor(t *testing.T) {
+	tests := []struct {
+		name      string
+		policy    model.SLAPolicy
+		wantStart time.Duration
+		wantEnd   time.Duration
+		wantZone  string
+		wantDays  map[time.Weekday]bool
+	}{
+		{
+			name:      "configured",
+			policy:    model.SLAPolicy{Timezone: "Asia/Jakarta", BusinessStart: "08:30", BusinessEnd: "16:00", BusinessDays: "1, 2,3"},
+			wantStart: 8*time.Hour + 30*time.Minute,
+			wantEnd:   16 * time.Hour,
+			wantZone:  "Asia/Jakarta",
+			wantDays:  weekdays(time.Monday, time.Tuesday, time.Wednesday),
+		},
+		{
+			name:      "malformed falls back",
+			policy:    model.SLAPolicy{Timezone: "Mars/Olympus", BusinessStart: "17:00", BusinessEnd: "09:00", BusinessDays: "0,7,x"},
+			wantStart: 9 * time.Hour,
+			wantEnd:   17 * time.Hour,
+			wantZone:  "UTC",
+			wantDays:  weekdays(time.Sunday),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ScheduleFor(tt.policy)
+			if got.Start != tt.wantStart || got.End != tt.wantEnd {
+				t.Errorf("hours = %s-%s, want %s-%s", got.Start, got.End, tt.wantStart, tt.wantEnd)
+			}
+			if got.Location.String() != tt.wantZone {
+				t.Errorf("location = %s, want %s", got.Location, tt.wantZone)
+			}
+			if len(got.Days) != len(tt.wantDays) {
+				t.Fatalf("days = %v, want %v", got.Days, tt.wantDays)
+			}
+			for day := range tt.wantDays {
+				if !got.Days[day] {
+					t.Errorf("days = %v, want %v", got.Days, tt.wantDays)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package sla
+
+import (
+	"backend/model"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// BreachingSoonWindow is how far ahead of a deadline a conversation counts as
+// breaching soon.
+const BreachingSoonWindow = 30 * time.Minute
+
+// PolicyFor returns the active policy for a channel: the team's own policy if
+// it has one, otherwise the tenant default.
+func PolicyFor(db *gorm.DB, channel model.Channel) (model.SLAPolicy, bool) {
+	var policy model.SLAPolicy
+	err := db.Where("tenant_id = ? AND is_active = ? AND team_id IN ?", channel.TenantID, true, []uint{0, channel.TeamID}).
+		Order("team_id DESC").
+		First(&policy).Error
+	return policy, err == nil
+}
+
+// Apply sets the channel's policy and both deadlines, counted from start.
+// It leaves the channel untouched when the tenant has no active policy.
+func Apply(db *gorm.DB, channel *model.Channel, start time.Time) bool {
+	policy, ok := PolicyFor(db, *channel)
+	if !ok {
+		return false
+	}
+
+	schedule := ScheduleFor(policy)
+	firstResponseDue := schedule.Add(start, time.Duration(policy.FirstResponseMinutes)*time.Minute)
+	resolutionDue := schedule.Add(start, time.Duration(policy.ResolutionMinutes)*time.Minute)
+
+	channel.SLAPolicyID = policy.ID
+	channel.FirstResponseDueAt = &firstResponseDue
+	channel.ResolutionDueAt = &resolutionDue
+	return true
+}
+
+// StoppedStatuses are the states in which no deadline can be breached: the
+// conversation is closed, or pending and snoozed ones wait on the customer.
+var StoppedStatuses = []string{model.ChannelClosed, model.ChannelPending, model.ChannelSnoozed}
+
+// Breached limits a channel query to conversations past a deadline they have
+// not met yet.
+func Breached(query *gorm.DB, now time.Time) *gorm.DB {
+	return query.Where("status NOT IN ?", StoppedStatuses).Where(
+		"((first_responded_at IS NULL AND first_response_due_at < ?) OR resolution_due_at < ?)",
+		now, now,
+	)
+}
+
+// BreachingSoon limits a channel query to conversations whose next unmet
+// deadline falls within window.
+func BreachingSoon(query *gorm.DB, now time.Time, window time.Duration) *gorm.DB {
+	until := now.Add(window)
+	return query.Where("status NOT IN ?", StoppedStatuses).Where(
+		"((first_responded_at IS NULL AND first_response_due_at BETWEEN ? AND ?) OR resolution_due_at BETWEEN ? AND ?)",
+		now, until, now, until,
+	)
+}
@@ -0,0 +1,58 @@
+package storage
+
+import (
+	"errors"
+	"io"
+	"log"
+	"os"
+)
+
+var ErrNotFound = errors.New("object not found")
+
+// Storage keeps uploaded files. Keys are slash separated and chosen by the
+// caller; backends must not let a key escape their own namespace.
+type Storage interface {
+	Put(key string, r io.Reader) error
+	Open(key string) (io.ReadCloser, error)
+	Delete(key string) error
+}
+
+var backends = map[string]func() (Storage, error){
+	"local": func() (Storage, error) {
+		return NewLocal(getEnv("STORAGE_PATH", "./uploads"))
+	},
+}
+
+var current Storage
+
+// Init selects the backend named by STORAGE_DRIVER, defaulting to the local
+// filesystem.
+func Init() {
+	driver := getEnv("STORAGE_DRIVER", "local")
+	factory, ok := backends[driver]
+	if !ok {
+		log.Fatalf("Unknown storage driver: %s", driver)
+	}
+
+	s, err := factory()
+	if err != nil {
+		log.Fatalf("Failed to init %s storage: %v", driver, err)
+	}
+	current = s
+}
+
+// Register adds a backend that STORAGE_DRIVER can select.
+func Register(name string, factory func() (Storage, error)) {
+	backends[name] = factory
+}
+
+func Default() Storage {
+	return current
+}
+
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
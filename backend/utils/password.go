@@ -1,9 +1,22 @@
 package utils
 
 import (
+	"fmt"
+
 	"golang.org/x/crypto/bcrypt"
 )
 
+// MinPasswordLength applies to every password that can be set: registration,
+// accepted invitations, admin-created accounts and admin resets.
+const MinPasswordLength = 8
+
+func ValidatePassword(p string) error {
+	if len(p) < MinPasswordLength {
+		return fmt.Errorf("password must be at least %d characters", MinPasswordLength)
+	}
+	return nil
+}
+
 func GeneratePassword(p string) string {
 	hash, _ := bcrypt.GenerateFromPassword([]byte(p), bcrypt.DefaultCost)
 	return string(hash)
@@ -0,0 +1,197 @@
+package utils
+
+import (
+	"backend/config"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	PresenceOnline  = "online"
+	PresenceAway    = "away"
+	PresenceOffline = "offline"
+
+	// A client that stops sending heartbeats drops to offline once its key
+	// expires, so a crashed tab never stays "online".
+	PresenceTTL = 90 * time.Second
+)
+
+type Presence struct {
+	UserID   uint      `json:"user_id"`
+	TenantID uint      `json:"tenant_id"`
+	Role     string    `json:"role"`
+	State    string    `json:"state"`
+	LastSeen time.Time `json:"last_seen"`
+}
+
+func presenceKey(tenantID, userID uint) string {
+	return fmt.Sprintf("presence:%d:%d", tenantID, userID)
+}
+
+func PresenceTopic(tenantID uint) string {
+	return fmt.Sprintf("presence:%d", tenantID)
+}
+
+// Heartbeat keys expire silently, so each tenant also keeps a sorted set of
+// "<userID>:<role>" scored by expiry that the sweeper uses to announce the
+// drop to offline.
+const presenceTenantsKey = "presence:tenants"
+
+func presenceIndexKey(tenantID uint) string {
+	return fmt.Sprintf("presence:index:%d", tenantID)
+}
+
+func presenceMember(p Presence) string {
+	return fmt.Sprintf("%d:%s", p.UserID, p.Role)
+}
+
+// SetPresence records a heartbeat. State changes are published so other
+// instances and listeners see them without polling.
+func SetPresence(p Presence) error {
+	key := presenceKey(p.TenantID, p.UserID)
+
+	if p.State == PresenceOffline {
+		if err := config.RedisClient.Del(config.Ctx, key).Err(); err != nil {
+			return err
+		}
+		config.RedisClient.ZRem(config.Ctx, presenceIndexKey(p.TenantID), presenceMember(p))
+		return PublishEvent(PresenceTopic(p.TenantID), "presence", p)
+	}
+
+	previous, _ := GetPresence(p.TenantID, p.UserID)
+
+	data, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	if err := config.RedisClient.Set(config.Ctx, key, data, PresenceTTL).Err(); err != nil {
+		return err
+	}
+	config.RedisClient.SAdd(config.Ctx, presenceTenantsKey, p.TenantID)
+	config.RedisClient.ZAdd(config.Ctx, presenceIndexKey(p.TenantID), redis.Z{
+		Score:  float64(time.Now().Add(PresenceTTL).Unix()),
+		Member: presenceMember(p),
+	})
+
+	if previous.State != p.State {
+		return PublishEvent(PresenceTopic(p.TenantID), "presence", p)
+	}
+	return nil
+}
+
+// GetPresence reports offline when no heartbeat is live.
+func GetPresence(tenantID, userID uint) (Presence, error) {
+	offline := Presence{UserID: userID, TenantID: tenantID, State: PresenceOffline}
+
+	data, err := config.RedisClient.Get(config.Ctx, presenceKey(tenantID, userID)).Bytes()
+	if err != nil {
+		return offline, nil
+	}
+
+	var p Presence
+	if err := json.Unmarshal(data, &p); err != nil {
+		return offline, err
+	}
+	return p, nil
+}
+
+// ListPresence returns everyone in the tenant with a live heartbeat.
+func ListPresence(tenantID uint) ([]Presence, error) {
+	var (
+		cursor uint64
+		result []Presence
+	)
+
+	for {
+		keys, next, err := config.RedisClient.Scan(config.Ctx, cursor, fmt.Sprintf("presence:%d:*", tenantID), 200).Result()
+		if err != nil {
+			return nil, err
+		}
+
+		if len(keys) > 0 {
+			values, err := config.RedisClient.MGet(config.Ctx, keys...).Result()
+			if err != nil {
+				return nil, err
+			}
+			for _, value := range values {
+				s, ok := value.(string)
+				if !ok {
+					continue
+				}
+				var p Presence
+				if json.Unmarshal([]byte(s), &p) == nil {
+					result = append(result, p)
+				}
+			}
+		}
+
+		cursor = next
+		if cursor == 0 {
+			return result, nil
+		}
+	}
+}
+
+// StartPresenceSweeper publishes an offline event for every heartbeat that
+// expired. ZRem decides which instance announces it, so each drop is sent once.
+func StartPresenceSweeper(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	go func() {
+		for range ticker.C {
+			tenants, err := config.RedisClient.SMembers(config.Ctx, presenceTenantsKey).Result()
+			if err != nil {
+				log.Printf("presence sweeper: %v", err)
+				continue
+			}
+			for _, tenant := range tenants {
+				tenantID, err := strconv.ParseUint(tenant, 10, 64)
+				if err == nil {
+					sweepPresence(uint(tenantID))
+				}
+			}
+		}
+	}()
+}
+
+func sweepPresence(tenantID uint) {
+	index := presenceIndexKey(tenantID)
+	expired, err := config.RedisClient.ZRangeByScore(config.Ctx, index, &redis.ZRangeBy{
+		Min: "-inf",
+		Max: strconv.FormatInt(time.Now().Unix(), 10),
+	}).Result()
+	if err != nil {
+		log.Printf("presence sweeper: %v", err)
+		return
+	}
+
+	for _, member := range expired {
+		userPart, role, _ := strings.Cut(member, ":")
+		userID, err := strconv.ParseUint(userPart, 10, 64)
+		if err != nil {
+			config.RedisClient.ZRem(config.Ctx, index, member)
+			continue
+		}
+
+		// A heartbeat may have arrived since the range was read.
+		if n, _ := config.RedisClient.Exists(config.Ctx, presenceKey(tenantID, uint(userID))).Result(); n > 0 {
+			continue
+		}
+		if removed, _ := config.RedisClient.ZRem(config.Ctx, index, member).Result(); removed == 0 {
+			continue
+		}
+
+		PublishEvent(PresenceTopic(tenantID), "presence", Presence{
+			UserID:   uint(userID),
+			TenantID: tenantID,
+			Role:     role,
+			State:    PresenceOffline,
+			LastSeen: time.Now(),
+		})
+	}
+}
@@ -0,0 +1,138 @@
+package utils
+
+import (
+	"backend/config"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/redis/go-redis/v9"
+)
+
+type Event struct {
+	Type string          `json:"type"`
+	Data json.RawMessage `json:"data"`
+}
+
+type Subscriber struct {
+	C     chan []byte
+	topic string
+}
+
+type topicSubscription struct {
+	pubsub      *redis.PubSub
+	subscribers map[*Subscriber]struct{}
+}
+
+var (
+	topicsMu sync.Mutex
+	topics   = map[string]*topicSubscription{}
+)
+
+func ChannelTopic(channelID uint) string {
+	return fmt.Sprintf("channel:%d", channelID)
+}
+
+func AgentTopic(agentID uint) string {
+	return fmt.Sprintf("agent:%d", agentID)
+}
+
+func PublishEvent(topic string, eventType string, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	eventJSON, err := json.Marshal(Event{Type: eventType, Data: payload})
+	if err != nil {
+		return err
+	}
+
+	return config.RedisClient.Publish(config.Ctx, topic, eventJSON).Err()
+}
+
+// Subscribe shares one Redis subscription per topic between all local
+// subscribers, so every instance fans out what any instance publishes.
+func Subscribe(topic string) (*Subscriber, error) {
+	sub := &Subscriber{
+		C:     make(chan []byte, 64),
+		topic: topic,
+	}
+
+	topicsMu.Lock()
+	if ts, ok := topics[topic]; ok {
+		ts.subscribers[sub] = struct{}{}
+		topicsMu.Unlock()
+		return sub, nil
+	}
+	topicsMu.Unlock()
+
+	// Wait for the subscription to be confirmed so callers can safely
+	// backfill from the database without missing anything published meanwhile.
+	// This talks to Redis, so it must not hold topicsMu.
+	pubsub := config.RedisClient.Subscribe(config.Ctx, topic)
+	if _, err := pubsub.Receive(config.Ctx); err != nil {
+		pubsub.Close()
+		return nil, err
+	}
+
+	topicsMu.Lock()
+	defer topicsMu.Unlock()
+
+	ts, ok := topics[topic]
+	if ok {
+		// Another caller subscribed the topic while we were waiting.
+		pubsub.Close()
+	} else {
+		ts = &topicSubscription{
+			pubsub:      pubsub,
+			subscribers: map[*Subscriber]struct{}{},
+		}
+		topics[topic] = ts
+		go ts.forward()
+	}
+	ts.subscribers[sub] = struct{}{}
+
+	return sub, nil
+}
+
+func (s *Subscriber) Close() {
+	topicsMu.Lock()
+	defer topicsMu.Unlock()
+
+	ts, ok := topics[s.topic]
+	if !ok {
+		return
+	}
+	if _, ok := ts.subscribers[s]; !ok {
+		return
+	}
+	ts.remove(s)
+}
+
+// remove detaches sub and closes its channel; callers must hold topicsMu.
+func (ts *topicSubscription) remove(sub *Subscriber) {
+	delete(ts.subscribers, sub)
+	close(sub.C)
+
+	if len(ts.subscribers) == 0 && topics[sub.topic] == ts {
+		delete(topics, sub.topic)
+		ts.pubsub.Close()
+	}
+}
+
+func (ts *topicSubscription) forward() {
+	for msg := range ts.pubsub.Channel() {
+		topicsMu.Lock()
+		for sub := range ts.subscribers {
+			select {
+			case sub.C <- []byte(msg.Payload):
+			default:
+				// Slow subscriber. Dropping the event would leave a gap, so
+				// close it instead and let the client reconnect and replay.
+				ts.remove(sub)
+			}
+		}
+		topicsMu.Unlock()
+	}
+}
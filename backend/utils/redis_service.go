@@ -4,7 +4,10 @@ import (
 	"backend/config"
 	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
+
+	"github.com/redis/go-redis/v9"
 )
 
 func IsRateLimited(key string, limit int, duration time.Duration) (bool, int) {
@@ -37,9 +40,12 @@ func ResetFailedLogin(email string) error {
 	return config.RedisClient.Del(config.Ctx, key).Err()
 }
 
-func StoreRefreshToken(userID uint, token string, expiration time.Duration) error {
+func StoreRefreshToken(userID uint, sessionID string, token string, expiration time.Duration) error {
 	key := fmt.Sprintf("refresh:%d:%s", userID, token)
-	return SetCache(key, true, expiration)
+	if err := SetCache(key, sessionID, expiration); err != nil {
+		return err
+	}
+	return SetCache(fmt.Sprintf("refresh:token:%s", token), userID, expiration)
 }
 
 func ValidateRefreshToken(userID uint, token string) bool {
@@ -48,15 +54,52 @@ func ValidateRefreshToken(userID uint, token string) bool {
 	return err == nil
 }
 
-func BlacklistToken(token string, expiration time.Duration) error {
-	key := fmt.Sprintf("blacklist:%s", token)
-	return SetCache(key, true, expiration)
+// ConsumeRefreshToken redeems a refresh token exactly once and returns the
+// owner and the session it belongs to. A token that was already redeemed
+// returns ErrRefreshTokenReused along with its owner so the caller can revoke
+// every session of that user.
+func ConsumeRefreshToken(token string, expiration time.Duration) (uint, string, error) {
+	var userID uint
+	var sessionID string
+
+	val, err := config.RedisClient.GetDel(config.Ctx, fmt.Sprintf("refresh:token:%s", token)).Result()
+	if err == redis.Nil {
+		if GetCache(fmt.Sprintf("refresh:used:%s", token), &userID) == nil {
+			return userID, "", ErrRefreshTokenReused
+		}
+		return 0, "", ErrInvalidToken
+	}
+	if err != nil {
+		return 0, "", err
+	}
+
+	if err := json.Unmarshal([]byte(val), &userID); err != nil {
+		return 0, "", ErrInvalidToken
+	}
+
+	key := fmt.Sprintf("refresh:%d:%s", userID, token)
+	if err := GetCache(key, &sessionID); err != nil {
+		return 0, "", ErrInvalidToken
+	}
+
+	DeleteCache(key)
+	SetCache(fmt.Sprintf("refresh:used:%s", token), userID, expiration)
+
+	return userID, sessionID, nil
 }
 
-func IsTokenBlacklisted(token string) bool {
-	key := fmt.Sprintf("blacklist:%s", token)
-	_, err := config.RedisClient.Get(config.Ctx, key).Result()
-	return err == nil
+// RevokeAllRefreshTokens walks the user's tokens with SCAN rather than KEYS,
+// which would block Redis while it scans the whole keyspace.
+func RevokeAllRefreshTokens(userID uint) error {
+	pattern := fmt.Sprintf("refresh:%d:*", userID)
+	prefix := fmt.Sprintf("refresh:%d:", userID)
+
+	iter := config.RedisClient.Scan(config.Ctx, 0, pattern, 100).Iterator()
+	for iter.Next(config.Ctx) {
+		key := iter.Val()
+		config.RedisClient.Del(config.Ctx, key, "refresh:token:"+strings.TrimPrefix(key, prefix))
+	}
+	return iter.Err()
 }
 
 func SetCache(key string, value interface{}, expiration time.Duration) error {
@@ -78,3 +121,13 @@ func GetCache(key string, dest interface{}) error {
 func DeleteCache(key string) error {
 	return config.RedisClient.Del(config.Ctx, key).Err()
 }
+
+// DeleteCachePattern removes every key matching pattern. It walks the keyspace
+// with SCAN so a large cache does not block Redis the way KEYS would.
+func DeleteCachePattern(pattern string) error {
+	iter := config.RedisClient.Scan(config.Ctx, 0, pattern, 100).Iterator()
+	for iter.Next(config.Ctx) {
+		config.RedisClient.Del(config.Ctx, iter.Val())
+	}
+	return iter.Err()
+}
@@ -0,0 +1,119 @@
+package utils
+
+import (
+	"backend/config"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func setupRedis(t *testing.T) *miniredis.Miniredis {
+	t.Helper()
+	server := miniredis.RunT(t)
+	previous := config.RedisClient
+	config.RedisClient = redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() {
+		config.RedisClient.Close()
+		config.RedisClient = previous
+	})
+	return server
+}
+
+func TestConsumeRefreshTokenRotates(t *testing.T) {
+	setupRedis(t)
+
+	if err := StoreRefreshToken(7, "session-a", "token-1", time.Hour); err != nil {
+		t.Fatal(err)
+	}
+
+	userID, sessionID, err := ConsumeRefreshToken("token-1", time.Hour)
+	if err != nil {
+		t.Fatalf("first use: %v", err)
+	}
+	if userID != 7 || sessionID != "session-a" {
+		t.Fatalf("first use = (%d, %q), want (7, %q)", userID, sessionID, "session-a")
+	}
+	if ValidateRefreshToken(7, "token-1") {
+		t.Error("token is still valid after being consumed")
+	}
+}
+
+func TestConsumeRefreshTokenDetectsReuse(t *testing.T) {
+	setupRedis(t)
+
+	StoreRefreshToken(7, "session-a", "token-1", time.Hour)
+	if _, _, err := ConsumeRefreshToken("token-1", time.Hour); err != nil {
+		t.Fatal(err)
+	}
+
+	userID, _, err := ConsumeRefreshToken("token-1", time.Hour)
+	if !errors.Is(err, ErrRefreshTokenReused) {
+		t.Fatalf("second use: err = %v, want ErrRefreshTokenReused", err)
+	}
+	if userID != 7 {
+		t.Errorf("second use: user = %d, want 7 so the caller can revoke their sessions", userID)
+	}
+}
+
+func TestConsumeRefreshTokenUnknown(t *testing.T) {
+	setupRedis(t)
+
+	if _, _, err := ConsumeRefreshToken("missing", time.Hour); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("err = %v, want ErrInvalidToken", err)
+	}
+}
+
+func TestConsumeRefreshTokenOnlyOnceConcurrently(t *testing.T) {
+	setupRedis(t)
+
+	StoreRefreshToken(7, "session-a", "token-1", time.Hour)
+
+	const attempts = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, attempts)
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _, err := ConsumeRefreshToken("token-1", time.Hour)
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	succeeded := 0
+	for err := range errs {
+		if err == nil {
+			succeeded++
+		}
+	}
+	if succeeded != 1 {
+		t.Fatalf("%d concurrent redemptions succeeded, want exactly 1", succeeded)
+	}
+}
+
+func TestRevokeAllRefreshTokens(t *testing.T) {
+	server := setupRedis(t)
+
+	StoreRefreshToken(7, "session-a", "token-1", time.Hour)
+	StoreRefreshToken(7, "session-b", "token-2", time.Hour)
+	StoreRefreshToken(8, "session-c", "token-3", time.Hour)
+
+	if err := RevokeAllRefreshTokens(7); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, token := range []string{"token-1", "token-2"} {
+		if ValidateRefreshToken(7, token) || server.Exists("refresh:token:"+token) {
+			t.Errorf("%s survived RevokeAllRefreshTokens", token)
+		}
+	}
+	if !ValidateRefreshToken(8, "token-3") {
+		t.Error("another user's token was revoked")
+	}
+}
@@ -0,0 +1,60 @@
+package utils
+
+import (
+	"bytes"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+)
+
+// maxThumbnailPixels caps the decoded size. A small file can declare huge
+// dimensions, and decoding it would allocate memory for every pixel.
+const maxThumbnailPixels = 40_000_000
+
+// Thumbnail decodes a PNG, JPEG or GIF and scales it so neither side exceeds
+// maxSide. It reports false when the data is not a decodable image or is
+// larger than maxThumbnailPixels.
+func Thumbnail(data []byte, maxSide int) ([]byte, bool) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width*cfg.Height > maxThumbnailPixels {
+		return nil, false
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, false
+	}
+
+	bounds := src.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	if w == 0 || h == 0 {
+		return nil, false
+	}
+
+	tw, th := w, h
+	if w > maxSide || h > maxSide {
+		if w >= h {
+			tw, th = maxSide, h*maxSide/w
+		} else {
+			tw, th = w*maxSide/h, maxSide
+		}
+		tw, th = max(tw, 1), max(th, 1)
+	}
+
+	// Nearest-neighbour sampling is enough for a preview and needs no
+	// dependency beyond the standard library.
+	dst := image.NewRGBA(image.Rect(0, 0, tw, th))
+	for y := 0; y < th; y++ {
+		sy := bounds.Min.Y + y*h/th
+		for x := 0; x < tw; x++ {
+			dst.Set(x, y, src.At(bounds.Min.X+x*w/tw, sy))
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 80}); err != nil {
+		return nil, false
+	}
+	return buf.Bytes(), true
+}
@@ -1,7 +1,9 @@
 package utils
 
 import (
+	"backend/model"
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/hex"
 	"errors"
 	"os"
@@ -13,6 +15,8 @@ import (
 var (
 	ErrInvalidToken = errors.New("invalid token")
 	ErrExpiredToken = errors.New("token has expired")
+
+	ErrRefreshTokenReused = errors.New("refresh token has already been used")
 )
 
 var (
@@ -23,12 +27,15 @@ var (
 
 type TokenDetails struct {
 	Token     string
+	JTI       string
 	ExpiresAt time.Time
 }
 
-func GenerateToken(userID uint, role string) (*TokenDetails, error) {
+func GenerateToken(user model.User, sessionID string) (*TokenDetails, error) {
 	var secretKey []byte
 
+	role := string(user.Role)
+
 	var expirationTime time.Time
 
 	switch role {
@@ -46,14 +53,20 @@ func GenerateToken(userID uint, role string) (*TokenDetails, error) {
 		expirationTime = time.Now().Add(24 * time.Hour)
 	}
 
+	jti := generateTokenID()
+
 	claims := jwt.MapClaims{
-		"user_id": userID,
-		"role":    role,
-		"exp":     expirationTime.Unix(),
-		"iat":     time.Now().Unix(),
-		"nbf":     time.Now().Unix(),
-		"iss":     "sociomile-backend",
-		"type":    "access",
+		"jti":         jti,
+		"sid":         sessionID,
+		"user_id":     user.ID,
+		"tenant_id":   user.TenantID,
+		"super_admin": user.IsSuperAdmin,
+		"role":        role,
+		"exp":         expirationTime.Unix(),
+		"iat":         time.Now().Unix(),
+		"nbf":         time.Now().Unix(),
+		"iss":         "sociomile-backend",
+		"type":        "access",
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
@@ -65,6 +78,7 @@ func GenerateToken(userID uint, role string) (*TokenDetails, error) {
 
 	return &TokenDetails{
 		Token:     tokenString,
+		JTI:       jti,
 		ExpiresAt: expirationTime,
 	}, nil
 }
@@ -108,6 +122,41 @@ func VerifyToken(tokenString string, role string) (*jwt.Token, jwt.MapClaims, er
 	return nil, nil, ErrInvalidToken
 }
 
+// ParseToken verifies a token against whichever role secret it was signed with.
+func ParseToken(tokenString string) (jwt.MapClaims, error) {
+	var lastErr error = ErrInvalidToken
+	for _, role := range []string{"admin", "agent", "user"} {
+		_, claims, err := VerifyToken(tokenString, role)
+		if err == nil {
+			return claims, nil
+		}
+		lastErr = err
+	}
+	return nil, lastErr
+}
+
+func GenerateSessionID() string {
+	return generateTokenID()
+}
+
+func generateTokenID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func GenerateInviteToken() string {
+	b := make([]byte, 32)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// HashToken is used for one-time tokens that are stored at rest.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
 func GenerateRefreshToken() string {
 	b := make([]byte, 32)
 	rand.Read(b)
@@ -0,0 +1,133 @@
+package utils
+
+import (
+	"backend/config"
+	"backend/model"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// TokenStore records revoked access tokens by their jti claim, plus per-user
+// cut-off times that revoke every token issued to a user before that moment.
+type TokenStore interface {
+	Revoke(jti string, expiresAt time.Time) error
+	IsRevoked(jti string) bool
+	RevokeUser(userID uint, expiresAt time.Time) error
+	IsUserRevoked(userID uint, issuedAt time.Time) bool
+}
+
+var Revocations TokenStore
+
+// MaxAccessTokenTTL is the longest lifetime GenerateToken hands out, so any
+// revocation older than this can be forgotten.
+const MaxAccessTokenTTL = 24 * time.Hour
+
+func InitTokenStore(db *gorm.DB) TokenStore {
+	switch os.Getenv("TOKEN_STORE") {
+	case "sql":
+		Revocations = &SQLTokenStore{DB: db}
+	default:
+		Revocations = &RedisTokenStore{}
+	}
+	return Revocations
+}
+
+type RedisTokenStore struct{}
+
+func (RedisTokenStore) Revoke(jti string, expiresAt time.Time) error {
+	ttl := time.Until(expiresAt)
+	if ttl <= 0 {
+		return nil
+	}
+	return SetCache(fmt.Sprintf("blacklist:%s", jti), true, ttl)
+}
+
+func (RedisTokenStore) IsRevoked(jti string) bool {
+	_, err := config.RedisClient.Get(config.Ctx, fmt.Sprintf("blacklist:%s", jti)).Result()
+	return err == nil
+}
+
+func (RedisTokenStore) RevokeUser(userID uint, expiresAt time.Time) error {
+	return SetCache(fmt.Sprintf("revoked_after:%d", userID), time.Now().Unix(), time.Until(expiresAt))
+}
+
+func (RedisTokenStore) IsUserRevoked(userID uint, issuedAt time.Time) bool {
+	var revokedAfter int64
+	if err := GetCache(fmt.Sprintf("revoked_after:%d", userID), &revokedAfter); err != nil {
+		return false
+	}
+	return revokedSince(issuedAt, revokedAfter)
+}
+
+type SQLTokenStore struct {
+	DB *gorm.DB
+}
+
+func (s *SQLTokenStore) Revoke(jti string, expiresAt time.Time) error {
+	return s.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&model.BlacklistedToken{
+		JTI:       jti,
+		ExpiresAt: expiresAt,
+	}).Error
+}
+
+func (s *SQLTokenStore) IsRevoked(jti string) bool {
+	var count int64
+	s.DB.Model(&model.BlacklistedToken{}).Where("jti = ?", jti).Count(&count)
+	return count > 0
+}
+
+// RevokeUser stores the cut-off as a "user:<id>" row whose updated_at is the
+// revocation time, so it shares the table and the sweeper with jti entries.
+func (s *SQLTokenStore) RevokeUser(userID uint, expiresAt time.Time) error {
+	return s.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "jti"}},
+		DoUpdates: clause.AssignmentColumns([]string{"updated_at", "expires_at"}),
+	}).Create(&model.BlacklistedToken{
+		JTI:       fmt.Sprintf("user:%d", userID),
+		ExpiresAt: expiresAt,
+	}).Error
+}
+
+func (s *SQLTokenStore) IsUserRevoked(userID uint, issuedAt time.Time) bool {
+	var row model.BlacklistedToken
+	err := s.DB.Where("jti = ? AND expires_at > ?", fmt.Sprintf("user:%d", userID), time.Now()).
+		First(&row).Error
+	if err != nil {
+		return false
+	}
+	return revokedSince(issuedAt, row.UpdatedAt.Unix())
+}
+
+// revokedSince compares at the one-second resolution of the iat claim. A token
+// issued in the same second as the revocation stays valid, so a user can log
+// straight back in; one minted just before it is caught by its session, which
+// the revocation also revokes by ID.
+func revokedSince(issuedAt time.Time, revokedAt int64) bool {
+	return issuedAt.Unix() < revokedAt
+}
+
+func SweepExpiredTokens(db *gorm.DB) (int64, error) {
+	result := db.Unscoped().Where("expires_at < ?", time.Now()).Delete(&model.BlacklistedToken{})
+	return result.RowsAffected, result.Error
+}
+
+func StartTokenSweeper(db *gorm.DB, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	go func() {
+		for range ticker.C {
+			deleted, err := SweepExpiredTokens(db)
+			if err != nil {
+				log.Printf("token sweeper: %v", err)
+				continue
+			}
+			if deleted > 0 {
+				log.Printf("token sweeper: removed %d expired tokens", deleted)
+			}
+		}
+	}()
+}
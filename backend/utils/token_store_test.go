@@ -0,0 +1,51 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRevokedSince(t *testing.T) {
+	revokedAt := time.Date(2026, 10, 12, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		issuedAt time.Time
+		want     bool
+	}{
+		{"issued earlier", revokedAt.Add(-time.Minute), true},
+		{"issued the second before", revokedAt.Add(-time.Second), true},
+		// The same second is left to the session check, so a user can log
+		// straight back in after a revocation.
+		{"issued in the same second", revokedAt.Add(500 * time.Millisecond), false},
+		{"issued later", revokedAt.Add(time.Second), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := revokedSince(tt.issuedAt, revokedAt.Unix()); got != tt.want {
+				t.Errorf("revokedSince(%s) = %v, want %v", tt.issuedAt, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedisTokenStoreRevokeUser(t *testing.T) {
+	setupRedis(t)
+	store := RedisTokenStore{}
+
+	before := time.Now().Add(-time.Minute)
+	if err := store.RevokeUser(7, time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	if !store.IsUserRevoked(7, before) {
+		t.Error("token issued before the revocation is still accepted")
+	}
+	if store.IsUserRevoked(7, time.Now().Add(time.Second)) {
+		t.Error("token issued after the revocation is rejected")
+	}
+	if store.IsUserRevoked(8, before) {
+		t.Error("another user's token is rejected")
+	}
+}
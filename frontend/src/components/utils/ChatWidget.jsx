@@ -44,6 +44,13 @@ export default function ChatWidget() {
     }, [messages]);
 
 
+    useEffect(() => {
+        if (isOpen && channel?.id && lastMessageId) {
+            axios.post(`/user/channels/${channel.id}/read`, { message_id: lastMessageId }).catch(console.error);
+        }
+    }, [isOpen, channel?.id, lastMessageId]);
+
+
     useEffect(() => {
         if (isAuthenticated && !channel) {
             checkExistingChannel();
@@ -172,7 +172,7 @@ export default function ChatWidgetAgent() {
             
             setMessages((prevMessages) => {
                 if (JSON.stringify(prevMessages) !== JSON.stringify(newMessages)) {
-                    axios.put(`/agent/channels/${channelId}/read`).catch(console.error);
+                    axios.post(`/agent/channels/${channelId}/read`).catch(console.error);
                     return newMessages;
                 }
                 return prevMessages;